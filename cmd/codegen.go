@@ -0,0 +1,128 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/codegen"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/schema"
+)
+
+var CodegenCmd = &cobra.Command{
+	Use:   "codegen",
+	Short: "Generate typed config code from a workspace",
+	Long: `Generates code describing the variables of a workspace. Keys are taken from
+the workspace schema and, when --env is set, from the variables defined in
+that environment.`,
+}
+
+var codegenGoCmd = &cobra.Command{
+	Use:   "go",
+	Short: "Generate a Go config struct",
+	Long: `Writes a Go file declaring a struct with one field per variable, tagged
+with its env name, and Load/LoadFrom functions that fill it. Types, defaults
+and keys required in every environment come from the schema; keys without a
+schema entry are strings. LoadFrom accepts any lookup function, so the struct
+can be filled from the process environment or from ryuk directly.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.Flags().Changed("output") {
+			exitcode.Exit(fmt.Errorf("--output does not apply to codegen, use --out-file to write to a file"))
+		}
+		pkg, _ := cmd.Flags().GetString("package")
+		typeName, _ := cmd.Flags().GetString("type")
+		output, _ := cmd.Flags().GetString("out-file")
+		path, _ := cmd.Flags().GetString("schema")
+		env := viper.GetString("env")
+
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if path == "" && ws.Project != "" {
+			path = schema.Path(ws.Project)
+		}
+		var s *schema.Schema
+		if path != "" {
+			s, err = schema.Load(path)
+			if err != nil {
+				exitcode.Fatal("Error loading schema", err, "path", path)
+			}
+		}
+		if s == nil && env == "" {
+			if path == "" {
+				exitcode.Exit(fmt.Errorf("Workspace %s has no project path, pass --schema or set --env to generate from an environment", ws.Name))
+			}
+			exitcode.Exit(fmt.Errorf("No schema found at %s, set --env to generate from an environment", path))
+		}
+
+		keys := []string{}
+		source := fmt.Sprintf("%s workspace", ws.Name)
+		if env != "" {
+			vars, err := resolve.Load(ws.Name, env, true)
+			if err != nil {
+				exitcode.Exit(err)
+			}
+			for key := range vars {
+				keys = append(keys, key)
+			}
+			source = fmt.Sprintf("%s environment of the %s workspace", env, ws.Name)
+		}
+		fields, err := codegen.Fields(keys, s)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+
+		var buf bytes.Buffer
+		if err := codegen.Go(&buf, pkg, typeName, source, fields); err != nil {
+			exitcode.Fatal("Codegen failed", err)
+		}
+		if output == "" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+			exitcode.Fatal("Error writing output file", err)
+		}
+	},
+}
+
+func init() {
+	CodegenCmd.AddCommand(codegenGoCmd)
+
+	CodegenCmd.PersistentFlags().StringP("workspace", "w", "default", "Workspace currently in use.")
+	CodegenCmd.PersistentFlags().StringP("env", "e", "", "Env to read keys from.")
+	CodegenCmd.PersistentFlags().String("schema", "", "Path of the schema file, defaults to the one in the project path")
+
+	codegenGoCmd.Flags().String("package", "config", "Package name of the generated file")
+	codegenGoCmd.Flags().String("type", "Config", "Name of the generated struct")
+	codegenGoCmd.Flags().String("out-file", "", "Write to this file instead of stdout")
+}
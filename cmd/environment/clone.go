@@ -0,0 +1,80 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package environment
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <src> <dst>",
+	Short: "Create an environment as a copy of another.",
+	Long: `Creates a new environment holding a copy of every variable defined in src.
+The new environment extends the same parent as src.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		src, dst := args[0], args[1]
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if err := ws.CheckEnv(src); err != nil {
+			exitcode.Exit(err)
+		}
+		srcConfig := ws.Environment[src]
+		if err := db.ValidateEnvName(dst); err != nil {
+			exitcode.Exit(err)
+		}
+		if _, ok := ws.Environment[dst]; ok {
+			exitcode.Exit(fmt.Errorf("Environment %s already exists in workspace %s", dst, ws.Name))
+		}
+
+		client, err := db.NewClient(ws, "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+		count, err := client.CloneBucket(src, dst)
+		client.Close()
+		if err != nil {
+			exitcode.Fatal("Error cloning environment", err)
+		}
+		config.UpdateWorkspace(ws.Name, dst)
+		if srcConfig.Parent != "" {
+			if err := config.SetParent(ws.Name, dst, srcConfig.Parent); err != nil {
+				exitcode.Exit(err)
+			}
+		}
+		log.Info("Cloned environment", "src", src, "dst", dst, "variables", count)
+	},
+}
+
+func init() {
+	EnvironmentCmd.AddCommand(cloneCmd)
+}
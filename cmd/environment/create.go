@@ -22,52 +22,82 @@ THE SOFTWARE.
 package environment
 
 import (
-	"path/filepath"
+	"fmt"
 
 	"github.com/charmbracelet/huh"
-	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/Brian-Kariu/ryuk/cmd/flags"
 	"github.com/Brian-Kariu/ryuk/config"
 	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
 )
 
-func createEnv(envName string) {
-	client, err := db.NewClient(filepath.Join(config.BasePath, viper.GetString("workspace")), viper.GetString("env"))
+func createEnv(envName, parent string) {
+	if err := db.ValidateEnvName(envName); err != nil {
+		exitcode.Exit(err)
+	}
+	ws, err := config.GetWorkspace(viper.GetString("workspace"))
+	if err != nil {
+		exitcode.Exit(err)
+	}
+	if parent != "" {
+		if err := ws.CheckEnv(parent); err != nil {
+			exitcode.Exit(err)
+		}
+	}
+	client, err := db.NewClient(ws, "")
 	if err != nil {
-		log.Fatal("Error creating DB!")
+		exitcode.Fatal("Error opening DB", err)
+	}
+	err = client.CreateBucket(envName)
+	client.Close()
+	if err != nil {
+		exitcode.Fatal("Error creating environment", err)
 	}
-	client.CreateBucket(envName)
 	config.UpdateWorkspace(viper.GetString("workspace"), envName)
+	if parent != "" {
+		if err := config.SetParent(viper.GetString("workspace"), envName, parent); err != nil {
+			exitcode.Exit(err)
+		}
+	}
 }
 
 var createCmd = &cobra.Command{
-	Use:   "create",
+	Use:   "create [name]",
 	Short: "Create a new environment",
-	Args:  cobra.NoArgs,
+	Args:  cobra.MaximumNArgs(1),
 	Long: `Use this command to create a new resource. A resource
 	could be a workspace, environment or variable
+
+	The name can be passed as an argument or with --name. It is asked for
+	interactively when missing and running in a terminal.
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var envName string
-		input := huh.NewInput().
-			Title("Input env Name").
-			Prompt("?").
-			Value(&envName)
-		err := input.Run()
-		if err != nil {
-			log.Fatalf("Environment name not set!")
+		envName, _ := cmd.Flags().GetString("name")
+		parent, _ := cmd.Flags().GetString("extends")
+		if len(args) == 1 {
+			envName = args[0]
+		}
+		if envName == "" && flags.CanPrompt() {
+			input := huh.NewInput().
+				Title("Input env Name").
+				Prompt("?").
+				Value(&envName)
+			err := input.Run()
+			if err != nil {
+				exitcode.Exit(fmt.Errorf("Environment name not set!"))
+			}
 		}
 		if !viper.IsSet("workspace") {
-			log.Fatal("Workspace config not set.")
+			exitcode.Exit(fmt.Errorf("Workspace config not set."))
 		}
 
 		if envName == "" {
-			log.Fatal("Environment name not set")
+			exitcode.Exit(fmt.Errorf("Environment name not set"))
 		}
-		createEnv(envName)
+		createEnv(envName, parent)
 	},
 }
 
@@ -76,6 +106,7 @@ func init() {
 
 	myFlagSet := flags.NewCreateFlagSet(flags.Environment)
 	createCmd.Flags().AddFlagSet(myFlagSet)
+	createCmd.Flags().String("extends", "", "Environment to inherit variables from")
 
 	createCmd.MarkPersistentFlagRequired("workspace")
 
@@ -23,20 +23,65 @@ package environment
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/Brian-Kariu/ryuk/cmd/flags"
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
 )
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
-	Use:   "delete",
-	Short: "Removes the current environment.",
-	Long:  `Deletes your current environment.`,
+	Use:   "delete [name]",
+	Short: "Removes an environment.",
+	Long: `Deletes an environment and all of its variables and history. Environments
+that still hold variables are only deleted with --force. A snapshot of the
+deleted data is kept and can be brought back with ryuk env restore.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("deleted environment.")
+		envName, _ := cmd.Flags().GetString("name")
+		force, _ := cmd.Flags().GetBool("force")
+		if len(args) == 1 {
+			envName = args[0]
+		}
+		if envName == "" {
+			exitcode.Exit(fmt.Errorf("Environment name not set"))
+		}
+
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if err := ws.CheckEnv(envName); err != nil {
+			exitcode.Exit(err)
+		}
+		if children := ws.Children(envName); len(children) > 0 {
+			exitcode.Exit(fmt.Errorf("Environment %s is extended by %s", envName, strings.Join(children, ", ")))
+		}
+		client, err := db.NewClient(ws, "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+
+		var snapshotPath string
+		err = client.DeleteBucket(envName, force, func(snapshot db.Snapshot) error {
+			snapshot.Config = ws.Environment[envName]
+			snapshotPath, err = db.WriteSnapshot(config.SnapshotDir(ws), snapshot)
+			return err
+		})
+		client.Close()
+		if err != nil {
+			exitcode.Fatal("Error deleting environment", err)
+		}
+		if err := config.RemoveEnvironment(ws.Name, envName); err != nil {
+			exitcode.Exit(err)
+		}
+		log.Info("Deleted environment", "env", envName, "snapshot", snapshotPath)
 	},
 }
 
@@ -45,6 +90,5 @@ func init() {
 
 	myFlagSet := flags.NewDeleteFlagSet("environment")
 	deleteCmd.Flags().AddFlagSet(myFlagSet)
-
-	viper.BindPFlags(createCmd.Flags())
+	deleteCmd.Flags().Bool("force", false, "Delete the environment even if it still has variables")
 }
@@ -0,0 +1,141 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package environment
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/diff"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/secret"
+)
+
+// loadEnvRef loads the effective variables of an env or workspace/env
+// reference, along with the keys that hold secrets. With own set, inherited
+// and global variables are left out.
+func loadEnvRef(ref string, raw, own bool) (map[string]string, map[string]bool, error) {
+	workspace, env, err := resolve.ParseEnvRef(ref, viper.GetString("workspace"))
+	if err != nil {
+		return nil, nil, err
+	}
+	vars, err := resolve.Load(workspace, env, raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", ref, err)
+	}
+	secrets := map[string]bool{}
+	for key, v := range vars {
+		if own && v.Layer != env {
+			delete(vars, key)
+			continue
+		}
+		if v.Secret {
+			secrets[key] = true
+		}
+	}
+	return resolve.Values(vars), secrets, nil
+}
+
+// maskChanges hides the values of changed variables. With showValues only
+// secrets are hidden, unless reveal is set as well.
+func maskChanges(result diff.Result, secrets map[string]bool, showValues, reveal bool) diff.Result {
+	masked := make([]diff.Change, 0, len(result.Changed))
+	for _, change := range result.Changed {
+		if !showValues || (secrets[change.Key] && !reveal) {
+			change = diff.Change{Key: change.Key, A: secret.Mask, B: secret.Mask}
+		}
+		masked = append(masked, change)
+	}
+	result.Changed = masked
+	return result
+}
+
+// diffStatus returns the exit code of ryuk env diff. Differences only fail
+// the command with --exit-code.
+func diffStatus(result diff.Result, exitCode bool) int {
+	if exitCode && !result.Empty() {
+		return exitcode.Differences
+	}
+	return exitcode.OK
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <envA> <envB>",
+	Short: "Compare the variables of two environments.",
+	Long: `Lists the variables that only exist in one of two environments and the
+ones whose values differ. Environments of another workspace can be given as
+workspace/env. Values are masked unless --show-values is set, and secrets
+stay masked unless --reveal is set too.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		raw, _ := cmd.Flags().GetBool("raw")
+		showValues, _ := cmd.Flags().GetBool("show-values")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		exitCode, _ := cmd.Flags().GetBool("exit-code")
+
+		a, secretsA, err := loadEnvRef(args[0], raw, false)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		b, secretsB, err := loadEnvRef(args[1], raw, false)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		for key := range secretsB {
+			secretsA[key] = true
+		}
+
+		result := maskChanges(diff.Compare(a, b), secretsA, showValues, reveal)
+
+		rows := [][]string{}
+		for _, key := range result.OnlyInA {
+			rows = append(rows, []string{"-", key, "only in " + args[0]})
+		}
+		for _, key := range result.OnlyInB {
+			rows = append(rows, []string{"+", key, "only in " + args[1]})
+		}
+		for _, change := range result.Changed {
+			rows = append(rows, []string{"~", change.Key, fmt.Sprintf("%s -> %s", change.A, change.B)})
+		}
+		if err := output.Render(config.Output, result, rows, nil); err != nil {
+			exitcode.Exit(err)
+		}
+		if code := diffStatus(result, exitCode); code != exitcode.OK {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	EnvironmentCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().Bool("show-values", false, "Show the values of changed variables")
+	diffCmd.Flags().Bool("reveal", false, "Show the values of secrets as well, with --show-values")
+	diffCmd.Flags().Bool("raw", false, "Compare values without expanding ${...} references")
+	diffCmd.Flags().Bool("exit-code", false, "Exit with status 2 when the environments differ")
+}
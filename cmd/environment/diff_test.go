@@ -0,0 +1,78 @@
+package environment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Brian-Kariu/ryuk/internal/diff"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/secret"
+)
+
+func TestMaskChanges(t *testing.T) {
+	result := diff.Compare(
+		map[string]string{"HOST": "a", "API_TOKEN": "t1", "OLD": "x"},
+		map[string]string{"HOST": "b", "API_TOKEN": "t2", "NEW": "y"},
+	)
+	secrets := map[string]bool{"API_TOKEN": true}
+	tests := []struct {
+		name               string
+		showValues, reveal bool
+		want               []diff.Change
+	}{
+		{"default", false, false, []diff.Change{
+			{Key: "API_TOKEN", A: secret.Mask, B: secret.Mask},
+			{Key: "HOST", A: secret.Mask, B: secret.Mask},
+		}},
+		{"reveal without show-values", false, true, []diff.Change{
+			{Key: "API_TOKEN", A: secret.Mask, B: secret.Mask},
+			{Key: "HOST", A: secret.Mask, B: secret.Mask},
+		}},
+		{"show-values", true, false, []diff.Change{
+			{Key: "API_TOKEN", A: secret.Mask, B: secret.Mask},
+			{Key: "HOST", A: "a", B: "b"},
+		}},
+		{"show-values and reveal", true, true, []diff.Change{
+			{Key: "API_TOKEN", A: "t1", B: "t2"},
+			{Key: "HOST", A: "a", B: "b"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := maskChanges(result, secrets, tt.showValues, tt.reveal)
+			if !reflect.DeepEqual(got.Changed, tt.want) {
+				t.Errorf("Changed = %+v, want %+v", got.Changed, tt.want)
+			}
+			// Only values are masked, never which keys differ.
+			if !reflect.DeepEqual(got.OnlyInA, result.OnlyInA) || !reflect.DeepEqual(got.OnlyInB, result.OnlyInB) {
+				t.Errorf("masking changed the added or removed keys: %+v", got)
+			}
+		})
+	}
+	if result.Changed[0].A != "t1" {
+		t.Error("maskChanges modified the result it was given")
+	}
+}
+
+func TestDiffStatus(t *testing.T) {
+	same := diff.Compare(map[string]string{"A": "1"}, map[string]string{"A": "1"})
+	different := diff.Compare(map[string]string{"A": "1"}, map[string]string{"A": "2"})
+	tests := []struct {
+		name     string
+		result   diff.Result
+		exitCode bool
+		want     int
+	}{
+		{"same", same, false, exitcode.OK},
+		{"same with --exit-code", same, true, exitcode.OK},
+		{"different", different, false, exitcode.OK},
+		{"different with --exit-code", different, true, exitcode.Differences},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffStatus(tt.result, tt.exitCode); got != tt.want {
+				t.Errorf("diffStatus = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package environment
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+)
+
+var extendCmd = &cobra.Command{
+	Use:   "extend <name> [parent]",
+	Short: "Set the parent of an environment.",
+	Long: `Makes an environment inherit the variables of a parent environment.
+Variables defined in the environment itself override inherited ones. Use
+--clear to stop inheriting.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		clear, _ := cmd.Flags().GetBool("clear")
+		parent := ""
+		if len(args) == 2 {
+			parent = args[1]
+		}
+		if parent == "" && !clear {
+			exitcode.Exit(fmt.Errorf("Parent environment not set, use --clear to remove the parent"))
+		}
+
+		if err := config.SetParent(viper.GetString("workspace"), args[0], parent); err != nil {
+			exitcode.Exit(err)
+		}
+		if parent == "" {
+			log.Info("Environment no longer extends another", "env", args[0])
+			return
+		}
+		log.Info("Environment extended", "env", args[0], "parent", parent)
+	},
+}
+
+func init() {
+	EnvironmentCmd.AddCommand(extendCmd)
+
+	extendCmd.Flags().Bool("clear", false, "Remove the parent of the environment")
+}
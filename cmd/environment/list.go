@@ -24,18 +24,18 @@ package environment
 import (
 	"fmt"
 	"io"
-	"maps"
-	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
 )
 
 var (
@@ -84,6 +84,12 @@ func (d envItemDelegate) Render(w io.Writer, m list.Model, index int, listItem l
 	fmt.Fprint(w, fn(str))
 }
 
+// envOutput is how an environment is rendered by --output json and yaml.
+type envOutput struct {
+	Name   string `json:"name" yaml:"name"`
+	Parent string `json:"parent,omitempty" yaml:"parent,omitempty"`
+}
+
 type envModel struct {
 	list     list.Model
 	choice   string
@@ -137,45 +143,53 @@ var listCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		err := viper.UnmarshalKey("workspaces", &config.Workspaces)
 		if err != nil {
-			log.Fatal("Error fetching workspaces:", err)
+			exitcode.Fatal("Error fetching workspaces", err)
 			return
 		}
 		if len(config.Workspaces) == 0 {
-			log.Fatal("No workspaces found.\n")
+			exitcode.Exit(fmt.Errorf("No workspaces found."))
 			return
 		}
 
 		currentWorkspace, err := config.GetWorkspace(viper.GetString("workspace"))
 		if err != nil {
-			log.Fatal("Error fetching current workspace:", err)
-		}
-
-		if len(currentWorkspace.Environment) == 0 {
-			log.Printf("[]")
+			exitcode.Fatal("Error fetching current workspace", err)
 		}
 
 		items := []list.Item{}
-		var envs []string
-		if len(currentWorkspace.Environment) != 0 {
-			envs = slices.Collect(maps.Keys(currentWorkspace.Environment))
+		envs := []string{}
+		rows := [][]string{}
+		data := []envOutput{}
+		for env := range currentWorkspace.Environment {
+			envs = append(envs, env)
 		}
+		sort.Strings(envs)
 		for _, env := range envs {
 			title := env
-			items = append(items, envitem{title: title, desc: ""})
+			desc := ""
+			if parent := currentWorkspace.Environment[env].Parent; parent != "" {
+				desc = "extends " + parent
+			}
+			items = append(items, envitem{title: title, desc: desc})
+			rows = append(rows, []string{env, currentWorkspace.Environment[env].Parent})
+			data = append(data, envOutput{Name: env, Parent: currentWorkspace.Environment[env].Parent})
 		}
 
-		l := list.New(items, list.NewDefaultDelegate(), 14, 20)
-		l.Title = fmt.Sprintf("Listing envs in %s workspace", currentWorkspace.Name)
-		l.SetShowStatusBar(false)
-		l.SetFilteringEnabled(false)
-		l.Styles.Title = titleStyle
-		l.Styles.PaginationStyle = paginationStyle
-		l.Styles.HelpStyle = helpStyle
-		log.Info("Envs: ", "list", items)
-		m := envModel{list: l}
-
-		if _, err := tea.NewProgram(m).Run(); err != nil {
-			log.Fatal("Error rendering envs: ", err)
+		err = output.Render(config.Output, data, rows, func() error {
+			l := list.New(items, list.NewDefaultDelegate(), 14, 20)
+			l.Title = fmt.Sprintf("Listing envs in %s workspace", currentWorkspace.Name)
+			l.SetShowStatusBar(false)
+			l.SetFilteringEnabled(false)
+			l.Styles.Title = titleStyle
+			l.Styles.PaginationStyle = paginationStyle
+			l.Styles.HelpStyle = helpStyle
+			m := envModel{list: l}
+
+			_, err := tea.NewProgram(m).Run()
+			return err
+		})
+		if err != nil {
+			exitcode.Fatal("Error rendering envs", err)
 		}
 	},
 }
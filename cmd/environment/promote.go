@@ -0,0 +1,208 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package environment
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/cmd/flags"
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/diff"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+)
+
+// promotionOutput is how a promotion is rendered by --output json and yaml.
+type promotionOutput struct {
+	Added   []string         `json:"added" yaml:"added"`
+	Changed []promotedChange `json:"changed" yaml:"changed"`
+}
+
+type promotedChange struct {
+	Key  string `json:"key" yaml:"key"`
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+	To   string `json:"to,omitempty" yaml:"to,omitempty"`
+}
+
+// printPromotion renders what result would write into dst. A is the value
+// promoted from src, B the one dst has now.
+func printPromotion(result diff.Result, showValues bool) error {
+	data := promotionOutput{Added: result.OnlyInA, Changed: []promotedChange{}}
+	rows := [][]string{}
+	for _, key := range result.OnlyInA {
+		rows = append(rows, []string{"+", key})
+	}
+	for _, change := range result.Changed {
+		if !showValues {
+			data.Changed = append(data.Changed, promotedChange{Key: change.Key})
+			rows = append(rows, []string{"~", change.Key})
+			continue
+		}
+		data.Changed = append(data.Changed, promotedChange{Key: change.Key, From: change.B, To: change.A})
+		rows = append(rows, []string{"~", change.Key, fmt.Sprintf("%s -> %s", change.B, change.A)})
+	}
+	return output.Render(config.Output, data, rows, nil)
+}
+
+// selectKeys keeps the additions and changes of result whose key is in keys.
+// An empty keys selects everything.
+func selectKeys(result diff.Result, keys []string) diff.Result {
+	if len(keys) == 0 {
+		return result
+	}
+	wanted := map[string]bool{}
+	for _, key := range keys {
+		wanted[key] = true
+	}
+
+	selected := diff.Result{OnlyInA: []string{}, OnlyInB: []string{}, Changed: []diff.Change{}}
+	for _, key := range result.OnlyInA {
+		if wanted[key] {
+			selected.OnlyInA = append(selected.OnlyInA, key)
+			delete(wanted, key)
+		}
+	}
+	for _, change := range result.Changed {
+		if wanted[change.Key] {
+			selected.Changed = append(selected.Changed, change)
+			delete(wanted, change.Key)
+		}
+	}
+	for key := range wanted {
+		log.Warn("Key has nothing to promote", "key", key)
+	}
+	return selected
+}
+
+func promotedConfig(key, value string, isSecret bool) db.Config {
+	c := db.Config{Key: []byte(key), Value: []byte(value)}
+	if isSecret {
+		c.Secret = &isSecret
+	}
+	return c
+}
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <src> <dst>",
+	Short: "Copy changed variables from one environment into another.",
+	Long: `Shows the variables that are new or different in src compared to dst and
+writes the selected ones into dst in a single transaction. Only variables set
+in src itself are promoted, not the ones it inherits or the global ones; they
+are compared with what dst currently resolves to. src can belong to another
+workspace (workspace/env); dst is in the current workspace. Values are copied
+without expanding ${...} references so they resolve against dst.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		keys, _ := cmd.Flags().GetStringSlice("keys")
+		yes, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		showValues, _ := cmd.Flags().GetBool("show-values")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		dst := args[1]
+
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if err := ws.CheckEnv(dst); err != nil {
+			exitcode.Exit(err)
+		}
+		src, secrets, err := loadEnvRef(args[0], true, true)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		current, dstSecrets, err := loadEnvRef(dst, true, false)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+
+		result := selectKeys(diff.Compare(src, current), keys)
+		if len(result.OnlyInA) == 0 && len(result.Changed) == 0 {
+			log.Info("Nothing to promote", "src", args[0], "dst", dst)
+			return
+		}
+		shown := map[string]bool{}
+		for key := range dstSecrets {
+			shown[key] = true
+		}
+		for key := range secrets {
+			shown[key] = true
+		}
+		if err := printPromotion(maskChanges(result, shown, showValues, reveal), showValues); err != nil {
+			exitcode.Exit(err)
+		}
+		if dryRun {
+			return
+		}
+
+		if !yes {
+			if !flags.CanPrompt() {
+				exitcode.Exit(fmt.Errorf("Refusing to promote without confirmation, pass --yes"))
+			}
+			err := huh.NewConfirm().
+				Title(fmt.Sprintf("Promote these changes into %s?", dst)).
+				Affirmative("Yes!").
+				Negative("No.").
+				Value(&yes).
+				Run()
+			if err != nil || !yes {
+				log.Info("Aborted.")
+				return
+			}
+		}
+
+		// Secrets stay secret in dst even if only src classified them.
+		configs := []db.Config{}
+		for _, key := range result.OnlyInA {
+			configs = append(configs, promotedConfig(key, src[key], secrets[key]))
+		}
+		for _, change := range result.Changed {
+			configs = append(configs, promotedConfig(change.Key, change.A, secrets[change.Key]))
+		}
+		client, err := db.NewClient(ws, "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+		summary, err := client.PromoteKeys(dst, configs)
+		client.Close()
+		if err != nil {
+			exitcode.Fatal("Promotion failed", err)
+		}
+		log.Info("Promoted variables", "dst", dst, "added", len(summary.Added), "changed", len(summary.Changed))
+	},
+}
+
+func init() {
+	EnvironmentCmd.AddCommand(promoteCmd)
+
+	promoteCmd.Flags().StringSlice("keys", []string{}, "Only promote these keys")
+	promoteCmd.Flags().BoolP("yes", "y", false, "Apply the changes without asking for confirmation")
+	promoteCmd.Flags().Bool("dry-run", false, "Only show what would be promoted")
+	promoteCmd.Flags().Bool("show-values", false, "Show the values of changed variables")
+	promoteCmd.Flags().Bool("reveal", false, "Show the values of secrets as well, with --show-values")
+}
@@ -0,0 +1,52 @@
+package environment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Brian-Kariu/ryuk/internal/diff"
+)
+
+func TestSelectKeys(t *testing.T) {
+	result := diff.Compare(
+		map[string]string{"NEW": "1", "HOST": "a", "PORT": "80", "SAME": "x"},
+		map[string]string{"HOST": "b", "PORT": "81", "SAME": "x", "ONLY_DST": "y"},
+	)
+	tests := []struct {
+		name string
+		keys []string
+		want diff.Result
+	}{
+		{
+			name: "everything",
+			want: result,
+		},
+		{
+			name: "some keys",
+			keys: []string{"NEW", "PORT"},
+			want: diff.Result{OnlyInA: []string{"NEW"}, OnlyInB: []string{}, Changed: []diff.Change{{Key: "PORT", A: "80", B: "81"}}},
+		},
+		{
+			name: "keys with nothing to promote",
+			keys: []string{"SAME", "ONLY_DST", "MISSING"},
+			want: diff.Result{OnlyInA: []string{}, OnlyInB: []string{}, Changed: []diff.Change{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectKeys(result, tt.keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectKeys = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromotedConfig(t *testing.T) {
+	if c := promotedConfig("PORT", "80", false); c.Secret != nil {
+		t.Error("a plain variable was classified explicitly")
+	}
+	c := promotedConfig("TOKEN", "t", true)
+	if c.Secret == nil || !*c.Secret || string(c.Key) != "TOKEN" || string(c.Value) != "t" {
+		t.Errorf("promotedConfig = %+v, want TOKEN=t marked secret", c)
+	}
+}
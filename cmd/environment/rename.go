@@ -0,0 +1,86 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package environment
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+)
+
+// renameEnv renames env in the database and then in the config. The store
+// is closed before returning so the workspace is released on failure too.
+func renameEnv(ws config.WorkspaceConfig, env, newEnv string) error {
+	client, err := db.NewClient(ws, "")
+	if err != nil {
+		return fmt.Errorf("Error opening DB: %w", err)
+	}
+	defer client.Close()
+	if err := client.RenameBucket(env, newEnv); err != nil {
+		return err
+	}
+	if err := config.RenameEnvironment(ws.Name, env, newEnv); err != nil {
+		// Keep the database in line with the config that is still on disk.
+		client.RenameBucket(newEnv, env)
+		return err
+	}
+	return nil
+}
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <name> <new-name>",
+	Short: "Rename an environment.",
+	Long: `Renames an environment along with its variables and history. Environments
+that extend it are updated to the new name.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		env, newEnv := args[0], args[1]
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if err := ws.CheckEnv(env); err != nil {
+			exitcode.Exit(err)
+		}
+		if err := db.ValidateEnvName(newEnv); err != nil {
+			exitcode.Exit(err)
+		}
+		if _, ok := ws.Environment[newEnv]; ok {
+			exitcode.Exit(fmt.Errorf("Environment %s already exists in workspace %s", newEnv, ws.Name))
+		}
+
+		if err := renameEnv(ws, env, newEnv); err != nil {
+			exitcode.Fatal("Error renaming environment", err)
+		}
+		log.Info("Renamed environment", "from", env, "to", newEnv)
+	},
+}
+
+func init() {
+	EnvironmentCmd.AddCommand(renameCmd)
+}
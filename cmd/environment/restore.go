@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package environment
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Restores a deleted environment.",
+	Long: `Brings back an environment from the snapshot taken when it was deleted.
+The most recent snapshot is used unless one is given with --snapshot. The
+environment extends the same parent as before it was deleted.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		envName := args[0]
+		snapshotPath, _ := cmd.Flags().GetString("snapshot")
+
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if _, ok := ws.Environment[envName]; ok {
+			exitcode.Exit(fmt.Errorf("Environment %s already exists in workspace %s", envName, ws.Name))
+		}
+		if snapshotPath == "" {
+			snapshotPath, err = db.LatestSnapshot(config.SnapshotDir(ws), envName)
+			if err != nil {
+				exitcode.Exit(err)
+			}
+		}
+		snapshot, err := db.ReadSnapshot(snapshotPath)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if snapshot.Env != envName {
+			exitcode.Exit(fmt.Errorf("Snapshot %s belongs to environment %s", snapshotPath, snapshot.Env))
+		}
+
+		client, err := db.NewClient(ws, "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+		err = client.RestoreSnapshot(snapshot)
+		client.Close()
+		if err != nil {
+			exitcode.Fatal("Error restoring environment", err)
+		}
+		config.UpdateWorkspace(ws.Name, envName)
+		if parent := snapshot.Config.Parent; parent != "" {
+			if err := config.SetParent(ws.Name, envName, parent); err != nil {
+				log.Warn("Restored environment no longer extends its parent", "parent", parent, "err", err)
+			}
+		}
+		if err := os.Remove(snapshotPath); err != nil {
+			log.Warn("Error removing snapshot", "err", err)
+		}
+		log.Info("Restored environment", "env", envName)
+	},
+}
+
+func init() {
+	EnvironmentCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().String("snapshot", "", "Snapshot file to restore from")
+}
@@ -0,0 +1,14 @@
+package flags
+
+import (
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// CanPrompt reports whether stdin is a terminal, in which case missing
+// inputs can be asked for with an interactive form.
+func CanPrompt() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
 )
 
 var InitCmd = &cobra.Command{
@@ -49,7 +50,9 @@ var InitCmd = &cobra.Command{
 		configFileInstance.checkDir()
 		configFileInstance.checkFile()
 
-		config.NewWorkspaceConfig(defaultDbName, "Default ryuk workspace", envs, false)
+		if err := config.NewWorkspaceConfig(defaultDbName, "Default ryuk workspace", envs, "", ""); err != nil {
+			exitcode.Exit(err)
+		}
 		initGlobalDb(config.BasePath)
 		log.Info("Ryuk app initalized!")
 		return
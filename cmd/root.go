@@ -22,9 +22,9 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
@@ -35,6 +35,8 @@ import (
 	"github.com/Brian-Kariu/ryuk/cmd/workspace"
 	"github.com/Brian-Kariu/ryuk/config"
 	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
 )
 
 var cfgFile string
@@ -83,9 +85,56 @@ var RootCmd = &cobra.Command{
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		configFileInstance := newConfigFile(config.BasePath, ".ryuk.yaml")
 		configFileInstance.check()
+		bindContextFlags(cmd)
+		applyContext(cmd)
+		if err := output.Validate(config.Output); err != nil {
+			exitcode.Exit(err)
+		}
 	},
 }
 
+// bindContextFlags points viper at the workspace and env flags of the
+// command being run. Several command groups define their own copies of these
+// flags and viper only keeps the last binding made during init.
+func bindContextFlags(cmd *cobra.Command) {
+	for _, name := range []string{"workspace", "env"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil {
+			viper.BindPFlag(name, flag)
+		}
+	}
+}
+
+// applyContext selects the workspace and env for commands run without -w
+// or -e. See config.ResolveContext for where they are looked up.
+func applyContext(cmd *cobra.Command) {
+	workspaceFlag := cmd.Flags().Lookup("workspace")
+	envFlag := cmd.Flags().Lookup("env")
+	if workspaceFlag == nil && envFlag == nil {
+		return
+	}
+	workspace := ""
+	if workspaceFlag != nil && workspaceFlag.Changed {
+		workspace = workspaceFlag.Value.String()
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	selection, err := config.ResolveContext(cwd, workspace)
+	if err != nil {
+		log.Warn("Ignoring project file", "err", err)
+	}
+	if workspaceFlag != nil && !workspaceFlag.Changed {
+		if _, err := config.GetWorkspace(selection.Workspace.Value); err != nil && selection.Workspace.Source != "default" {
+			log.Warn("Selected workspace does not exist", "workspace", selection.Workspace.Value, "from", selection.Workspace.Source)
+		}
+		viper.Set("workspace", selection.Workspace.Value)
+	}
+	if envFlag != nil && !envFlag.Changed {
+		viper.Set("env", selection.Env.Value)
+	}
+}
+
 func Execute() {
 	err := RootCmd.Execute()
 	if err != nil {
@@ -94,46 +143,27 @@ func Execute() {
 }
 
 func addSubcommands() {
-	RootCmd.AddCommand(workspace.WorkspaceCmd, environment.EnvironmentCmd, variables.VariablesCmd, InitCmd)
+	RootCmd.AddCommand(workspace.WorkspaceCmd, environment.EnvironmentCmd, variables.VariablesCmd, InitCmd, RunCmd, ValidateCmd, CodegenCmd, UseCmd, CurrentCmd)
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ryuk/ryuk.yaml)")
+	RootCmd.PersistentFlags().StringVarP(&config.Output, "output", "o", output.Table, "Output format: "+strings.Join(output.Formats, "|"))
 	RootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
 	addSubcommands()
 }
 
 func initGlobalDb(path string) {
-	dbInstance, err := db.NewClient(filepath.Join(path, "default"), "")
+	dbInstance, err := db.NewClient(config.WorkspaceConfig{DB: filepath.Join(path, "default")}, "")
 	if err != nil {
-		log.Error("Error creating DB, %v", err)
-	}
-	dbInstance.CreateBucket("prod")
-}
-
-func setCurrentWorkspace() {
-	verifiedWorkspace := ""
-	if viper.Get("workspace") == "" {
-		viper.Set("workspace", "default")
-		if err := viper.WriteConfig(); err != nil {
-			fmt.Errorf("Error saving current workspace: %v\n", err)
-		}
+		log.Error("Error creating DB", "err", err)
 		return
-
-	}
-	for _, ws := range config.Workspaces {
-		if ws.Name == config.CurrentWorkspace {
-			verifiedWorkspace = ws.Name
-			break
-		}
-	}
-	if verifiedWorkspace != "" {
-		viper.Set("workspace", verifiedWorkspace)
 	}
-	if err := viper.WriteConfig(); err != nil {
-		fmt.Errorf("Error saving current workspace: %v\n", err)
+	defer dbInstance.Close()
+	if err := dbInstance.CreateBucket("prod"); err != nil {
+		log.Error("Error creating DB", "err", err)
 	}
 }
 
@@ -155,7 +185,6 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		log.Info("Using config ", "file:", viper.ConfigFileUsed())
 	}
-	setCurrentWorkspace()
 
 	err := viper.UnmarshalKey("workspaces", &config.Workspaces)
 	if err != nil {
@@ -0,0 +1,136 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+)
+
+// forwardedSignals are relayed to the child so that wrapping a process in
+// ryuk run does not change how it is stopped.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// keyboardSignals are sent by the terminal to its whole foreground process
+// group, which the child shares with ryuk.
+var keyboardSignals = map[os.Signal]bool{os.Interrupt: true, syscall.SIGQUIT: true}
+
+// shouldForward reports whether sig is relayed to the child. Keyboard
+// signals already reached it when ryuk runs in the foreground, and sending
+// them again would make the child see Ctrl-C twice.
+func shouldForward(sig os.Signal, foreground bool) bool {
+	return !foreground || !keyboardSignals[sig]
+}
+
+// mergeEnv overlays vars on top of environ, replacing existing entries.
+func mergeEnv(environ []string, vars map[string]string) []string {
+	merged := make([]string, 0, len(environ)+len(vars))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := vars[key]; ok {
+			continue
+		}
+		merged = append(merged, kv)
+	}
+	for k, v := range vars {
+		merged = append(merged, k+"="+v)
+	}
+	return merged
+}
+
+func runWithVars(name string, args []string, vars map[string]string) int {
+	child := exec.Command(name, args...)
+	child.Env = mergeEnv(os.Environ(), vars)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	if err := child.Start(); err != nil {
+		log.Error("Error starting command", "command", name, "err", err)
+		return 127
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			if shouldForward(sig, inForeground()) {
+				child.Process.Signal(sig)
+			}
+		}
+	}()
+
+	err := child.Wait()
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+	log.Error("Error running command", "command", name, "err", err)
+	return 1
+}
+
+var RunCmd = &cobra.Command{
+	Use:   "run -- <command> [args...]",
+	Short: "Run a command with an environment's variables",
+	Long: `Loads the variables of an environment, adds them to the current process
+environment and runs the given command. Signals are forwarded to the command
+and ryuk exits with its exit code.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if viper.GetString("env") == "" {
+			exitcode.Exit(fmt.Errorf("Env flag not set! Pass --env or select one with ryuk use."))
+		}
+		raw, _ := cmd.Flags().GetBool("raw")
+		vars, err := resolve.Load(viper.GetString("workspace"), viper.GetString("env"), raw)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+
+		os.Exit(runWithVars(args[0], args[1:], resolve.Values(vars)))
+	},
+}
+
+func init() {
+	RunCmd.Flags().SetInterspersed(false)
+	RunCmd.Flags().StringP("workspace", "w", "default", "Workspace currently in use.")
+	RunCmd.Flags().StringP("env", "e", "", "Env currently in use.")
+	RunCmd.Flags().Bool("raw", false, "Pass values without expanding ${...} references")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestShouldForward(t *testing.T) {
+	tests := []struct {
+		sig        os.Signal
+		foreground bool
+		want       bool
+	}{
+		{os.Interrupt, true, false},
+		{syscall.SIGQUIT, true, false},
+		{syscall.SIGTERM, true, true},
+		{syscall.SIGHUP, true, true},
+		{os.Interrupt, false, true},
+		{syscall.SIGQUIT, false, true},
+		{syscall.SIGTERM, false, true},
+	}
+	for _, tt := range tests {
+		if got := shouldForward(tt.sig, tt.foreground); got != tt.want {
+			t.Errorf("shouldForward(%v, foreground %v) = %v, want %v", tt.sig, tt.foreground, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+)
+
+var UseCmd = &cobra.Command{
+	Use:   "use <workspace> [env]",
+	Short: "Select the workspace and env commands run against",
+	Long: `Saves the workspace and env used when -w and -e are omitted, like
+kubectl config use-context.
+
+With --shell the context is printed as export statements instead of being
+saved, so it only applies to the current shell:
+
+  eval "$(ryuk use myapp dev --shell)"`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := config.Context{Workspace: args[0]}
+		if len(args) > 1 {
+			ctx.Env = args[1]
+		}
+		shell, _ := cmd.Flags().GetBool("shell")
+		if shell {
+			ws, err := config.GetWorkspace(ctx.Workspace)
+			if err != nil {
+				exitcode.Exit(err)
+			}
+			if ctx.Env != "" {
+				if err := ws.CheckEnv(ctx.Env); err != nil {
+					exitcode.Exit(err)
+				}
+			}
+			fmt.Printf("export %s=%s\n", config.WorkspaceEnvVar, ctx.Workspace)
+			if ctx.Env != "" {
+				fmt.Printf("export %s=%s\n", config.EnvEnvVar, ctx.Env)
+			} else {
+				fmt.Printf("unset %s\n", config.EnvEnvVar)
+			}
+			return
+		}
+
+		if err := config.UseContext(ctx); err != nil {
+			exitcode.Fatal("Error saving context", err)
+		}
+		if ctx.Env != "" {
+			log.Printf("Using env %s of workspace %s.", ctx.Env, ctx.Workspace)
+		} else {
+			log.Printf("Using workspace %s.", ctx.Workspace)
+		}
+	},
+}
+
+var CurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the workspace and env commands run against",
+	Long: `Shows the workspace and env used when -w and -e are omitted and where
+each was taken from: the shell, a project file, a workspace's project path,
+ryuk use or the default.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			exitcode.Fatal("Error reading working directory", err)
+		}
+		selection, err := config.ResolveContext(cwd, "")
+		if err != nil {
+			log.Warn("Ignoring project file", "err", err)
+		}
+		rows := [][]string{
+			{"workspace", selection.Workspace.Value, selection.Workspace.Source},
+			{"env", selection.Env.Value, selection.Env.Source},
+		}
+		if err := output.Render(config.Output, selection, rows, nil); err != nil {
+			exitcode.Fatal("Error printing context", err)
+		}
+	},
+}
+
+func init() {
+	UseCmd.Flags().Bool("shell", false, "Print export statements for the current shell instead of saving")
+}
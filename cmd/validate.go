@@ -0,0 +1,118 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/schema"
+)
+
+var ValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check an environment against the workspace schema",
+	Long: `Checks the effective variables of an environment against the schema in
+the workspace's project path (` + schema.FileName + `) and lists the keys that are
+missing or invalid, or whose references can not be expanded. Exits with
+status 1 when there are problems, so it can gate deploys in CI.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		env := viper.GetString("env")
+		if env == "" {
+			exitcode.Exit(fmt.Errorf("Env flag not set! Pass --env or select one with ryuk use."))
+		}
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		path, _ := cmd.Flags().GetString("schema")
+		if path == "" {
+			if ws.Project == "" {
+				exitcode.Exit(fmt.Errorf("Workspace %s has no project path, pass --schema to select a schema file", ws.Name))
+			}
+			path = schema.Path(ws.Project)
+		}
+		s, err := schema.Load(path)
+		if err != nil {
+			exitcode.Fatal("Error loading schema", err, "path", path)
+		}
+		if s == nil {
+			exitcode.Exit(fmt.Errorf("No schema found at %s", path))
+		}
+
+		vars, failed, err := resolve.LoadEach(ws.Name, env)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		// A value that can not be expanded is reported as such rather than
+		// checked as stored.
+		problems := []schema.Problem{}
+		for _, problem := range s.Validate(env, resolve.Values(vars)) {
+			if failed[problem.Key] == nil {
+				problems = append(problems, problem)
+			}
+		}
+		for key, err := range failed {
+			message := strings.TrimPrefix(err.Error(), key+": ")
+			problems = append(problems, schema.Problem{Key: key, Message: message})
+		}
+		sort.Slice(problems, func(i, j int) bool {
+			return problems[i].Key < problems[j].Key
+		})
+		rows := [][]string{}
+		for _, problem := range problems {
+			rows = append(rows, []string{problem.Key, problem.Message})
+		}
+		err = output.Render(config.Output, problems, rows, func() error {
+			for _, problem := range problems {
+				fmt.Println(problem)
+			}
+			return nil
+		})
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		if config.Output == output.Table {
+			log.Info("Environment is valid", "env", env)
+		}
+	},
+}
+
+func init() {
+	ValidateCmd.Flags().StringP("workspace", "w", "default", "Workspace currently in use.")
+	ValidateCmd.Flags().StringP("env", "e", "", "Env currently in use.")
+	ValidateCmd.Flags().String("schema", "", "Path of the schema file, defaults to the one in the project path")
+}
@@ -23,15 +23,19 @@ package variables
 
 import (
 	"fmt"
-	"path/filepath"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/Brian-Kariu/ryuk/cmd/flags"
 	"github.com/Brian-Kariu/ryuk/config"
 	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/schema"
 )
 
 type Config struct {
@@ -40,64 +44,140 @@ type Config struct {
 }
 
 func createVar(bucket string, data db.Config) {
-	client, err := db.NewClient(filepath.Join(config.BasePath, viper.GetString("workspace")), bucket)
+	client, err := db.OpenWorkspace(viper.GetString("workspace"), "")
 	if err != nil {
-		fmt.Printf("Error creating DB!")
+		exitcode.Fatal("Error opening DB", err)
 	}
 	err = client.AddKey(bucket, data)
+	client.Close()
 	if err != nil {
-		log.Fatalf("Failed to add key: %v", err)
+		exitcode.Fatal("Failed to add key", err)
 	}
+	log.Printf("Added config: %s, to bucket: %s", data.Key, bucket)
+}
+
+// checkSchema validates value against the workspace schema, if there is one.
+// Values holding ${...} references are checked once expanded in env. When
+// they can not be expanded yet, or are global and so expand differently in
+// every environment, they are left to ryuk validate.
+func checkSchema(env, key, value string) error {
+	ws, err := config.GetWorkspace(viper.GetString("workspace"))
+	if err != nil {
+		return err
+	}
+	s, err := schema.ForProject(ws.Project)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(value, "${") {
+		if env == db.GlobalBucket {
+			return nil
+		}
+		expanded, err := resolve.ExpandValue(ws.Name, env, key, value)
+		if err != nil {
+			log.Warn("Value can not be expanded yet, check it later with ryuk validate", "key", key, "err", err)
+			return nil
+		}
+		value = expanded
+	}
+	return s.CheckValue(key, value)
 }
 
 var createCmd = &cobra.Command{
-	Use:   "create",
-	Short: "Create a new environment",
-	Args:  cobra.NoArgs,
-	Long: `Use this command to create a new resource. A resource
-	could be a workspace, environment or variable
+	Use:     "create [key] [value]",
+	Aliases: []string{"set"},
+	Short:   "Create or update a variable",
+	Args:    cobra.MaximumNArgs(2),
+	Long: `Use this command to set a variable in an environment.
+
+	The key and value can be passed as arguments, e.g. ryuk var set KEY VALUE.
+	Missing values are asked for interactively when running in a terminal.
+
+	With --global the variable is shared by every environment of the
+	workspace. Environments that set the same key override it.
+
+	Keys named like secrets, e.g. *_TOKEN or *_PASSWORD, are masked in
+	output. Use --secret to mark any other key as a secret, or
+	--secret=false to show a key that only looks like one.
+
+	--description, --tags and --owner document the variable. They are kept
+	when the value is changed later without them. The owner defaults to
+	whoever created the variable.
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
+		bucket := targetBucket(cmd)
 		var envName string
 		var envValue string
-		var confirm bool
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewInput().
-					Title("Input var name").
-					Placeholder("Value").
-					Value(&envName),
-				huh.NewInput().
-					Title("Input var value").
-					Placeholder("Value").
-					Value(&envValue),
-				huh.NewConfirm().
-					Title("Are you sure?").
-					Affirmative("Yes!").
-					Negative("No.").
-					Value(&confirm),
-			),
-		)
-		err := form.Run()
-		if err != nil {
-			log.Fatalf("Workspace name not set!")
+		if len(args) > 0 {
+			envName = args[0]
+		}
+		if len(args) > 1 {
+			envValue = args[1]
 		}
-		if !viper.IsSet("workspace") {
-			log.Fatal("Workspace flag not set!")
+
+		if len(args) < 2 {
+			if !flags.CanPrompt() {
+				exitcode.Exit(fmt.Errorf("Variable key and value not set!"))
+			}
+			var confirm bool
+			form := huh.NewForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title("Input var name").
+						Placeholder("Value").
+						Value(&envName),
+					huh.NewInput().
+						Title("Input var value").
+						Placeholder("Value").
+						Value(&envValue),
+					huh.NewConfirm().
+						Title("Are you sure?").
+						Affirmative("Yes!").
+						Negative("No.").
+						Value(&confirm),
+				),
+			)
+			err := form.Run()
+			if err != nil {
+				exitcode.Exit(fmt.Errorf("Variable key and value not set!"))
+			}
+			if !confirm {
+				log.Info("Aborted.")
+				return
+			}
 		}
-		if !viper.IsSet("env") {
-			log.Fatal("Env flag not set!")
+		if envName == "" {
+			exitcode.Exit(fmt.Errorf("Variable key not set!"))
+		}
+		if err := checkSchema(bucket, envName, envValue); err != nil {
+			exitcode.Fatal("Invalid value", err, "key", envName)
 		}
-		log.Info("Env Name: ", "key", envName)
-		log.Info("Env Value: ", "value", envValue)
 		data := db.Config{Key: []byte(envName), Value: []byte(envValue)}
-		createVar(viper.GetString("env"), data)
+		if cmd.Flags().Changed("secret") {
+			secret, _ := cmd.Flags().GetBool("secret")
+			data.Secret = &secret
+		}
+		if cmd.Flags().Changed("description") {
+			description, _ := cmd.Flags().GetString("description")
+			data.Description = &description
+		}
+		if cmd.Flags().Changed("tags") {
+			data.Tags, _ = cmd.Flags().GetStringSlice("tags")
+		}
+		if cmd.Flags().Changed("owner") {
+			owner, _ := cmd.Flags().GetString("owner")
+			data.Owner = &owner
+		}
+		createVar(bucket, data)
 	},
 }
 
 func init() {
 	VariablesCmd.AddCommand(createCmd)
 
-	createCmd.MarkPersistentFlagRequired("workspace")
-	createCmd.MarkPersistentFlagRequired("env")
+	createCmd.Flags().Bool("global", false, "Set the variable for every environment of the workspace")
+	createCmd.Flags().Bool("secret", false, "Mark the variable as a secret, or as plain with --secret=false")
+	createCmd.Flags().String("description", "", "What the variable is for")
+	createCmd.Flags().StringSlice("tags", []string{}, "Tags to filter the variable by, e.g. payments,db")
+	createCmd.Flags().String("owner", "", "Who is responsible for the variable")
 }
@@ -22,30 +22,36 @@ THE SOFTWARE.
 package variables
 
 import (
-	"path/filepath"
-
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
-	"github.com/Brian-Kariu/ryuk/config"
 	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete",
+	Use:   "delete <key>",
 	Short: "delete an environment variables",
-	Args:  cobra.MaximumNArgs(1),
+	Args:  cobra.ExactArgs(1),
 	Long:  `delete a specific environment variable`,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := db.NewClient(filepath.Join(config.BasePath, viper.GetString("workspace")), viper.GetString("env"))
+		bucket := targetBucket(cmd)
+		client, err := db.OpenWorkspace(viper.GetString("workspace"), "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+		err = client.DeleteKey(bucket, args[0])
+		client.Close()
 		if err != nil {
-			log.Error("Error creating DB!")
+			exitcode.Fatal("Delete operation failed", err)
 		}
-		client.DeleteKey(viper.GetString("env"), args[0])
+		log.Printf("Config %s has been deleted", args[0])
 	},
 }
 
 func init() {
 	VariablesCmd.AddCommand(deleteCmd)
+
+	deleteCmd.Flags().Bool("global", false, "Delete a global variable")
 }
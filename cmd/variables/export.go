@@ -0,0 +1,97 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package variables
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/internal/envfile"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/secret"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the variables of an environment",
+	Long: `Writes every variable in the selected environment to stdout, or to the file
+given with --out-file. The file format is chosen with --format, supported
+formats are ` + strings.Join(envfile.Formats, ", ") + `. Secrets are masked
+unless --reveal is passed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.Flags().Changed("output") {
+			exitcode.Exit(fmt.Errorf("--output does not apply to export, use --format to pick the file format"))
+		}
+		requireEnv()
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("out-file")
+
+		raw, _ := cmd.Flags().GetBool("raw")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		vars, err := resolve.Load(viper.GetString("workspace"), viper.GetString("env"), raw)
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		envVars := make(map[string]string, len(vars))
+		masked := 0
+		for key, v := range vars {
+			envVars[key] = secret.Show(v.Value, v.Secret, reveal)
+			if v.Secret && !reveal {
+				masked++
+			}
+		}
+		if masked > 0 {
+			log.Warn("Secrets were masked, pass --reveal to export them", "count", masked)
+		}
+
+		// Render everything first so an invalid value does not leave a
+		// half-written file behind.
+		var buf bytes.Buffer
+		if err := envfile.Write(&buf, format, envVars); err != nil {
+			exitcode.Fatal("Export failed", err)
+		}
+		if output == "" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := os.WriteFile(output, buf.Bytes(), 0600); err != nil {
+			exitcode.Fatal("Error writing output file", err)
+		}
+	},
+}
+
+func init() {
+	VariablesCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringP("format", "f", "dotenv", "File format: "+strings.Join(envfile.Formats, "|"))
+	exportCmd.Flags().String("out-file", "", "Write to this file instead of stdout")
+	exportCmd.Flags().Bool("raw", false, "Export values without expanding ${...} references")
+	exportCmd.Flags().Bool("reveal", false, "Export the values of secrets")
+}
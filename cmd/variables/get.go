@@ -22,30 +22,94 @@ THE SOFTWARE.
 package variables
 
 import (
-	"fmt"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/Brian-Kariu/ryuk/config"
-	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/secret"
 )
 
+// varDetails is how var get --details renders a variable.
+type varDetails struct {
+	Key         string    `json:"key" yaml:"key"`
+	Value       string    `json:"value" yaml:"value"`
+	Layer       string    `json:"layer" yaml:"layer"`
+	Secret      bool      `json:"secret" yaml:"secret"`
+	Description string    `json:"description" yaml:"description"`
+	Tags        []string  `json:"tags" yaml:"tags"`
+	Owner       string    `json:"owner" yaml:"owner"`
+	CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" yaml:"updated_at"`
+}
+
+func (d varDetails) rows(env string) [][]string {
+	return [][]string{
+		{"key", d.Key},
+		{"value", d.Value},
+		{"layer", layerLabel(d.Layer, env)},
+		{"secret", strconv.FormatBool(d.Secret)},
+		{"description", d.Description},
+		{"tags", strings.Join(d.Tags, ",")},
+		{"owner", d.Owner},
+		{"created", formatTimestamp(d.CreatedAt)},
+		{"updated", formatTimestamp(d.UpdatedAt)},
+	}
+}
+
 var getCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get an environment variables",
-	Args:  cobra.MaximumNArgs(1),
-	Long:  `Get a specific environment variable`,
+	Args:  cobra.ExactArgs(1),
+	Long: `Get a specific environment variable. Secrets are masked unless
+--reveal is passed. With --details the description, tags, owner and
+timestamps of the variable are shown as well.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := db.NewClient(filepath.Join(config.BasePath, viper.GetString("workspace")), viper.GetString("env"))
+		requireEnv()
+		raw, _ := cmd.Flags().GetBool("raw")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		details, _ := cmd.Flags().GetBool("details")
+		env := viper.GetString("env")
+		v, err := resolve.LookupVar(viper.GetString("workspace"), env, args[0], raw)
 		if err != nil {
-			fmt.Printf("Error creating DB!")
+			exitcode.Exit(err)
+		}
+
+		value := secret.Show(v.Value, v.Secret, reveal)
+		if details {
+			data := varDetails{
+				Key:         args[0],
+				Value:       value,
+				Layer:       v.Layer,
+				Secret:      v.Secret,
+				Description: v.Record.Description,
+				Tags:        v.Record.Tags,
+				Owner:       v.Record.Owner,
+				CreatedAt:   v.Record.CreatedAt,
+				UpdatedAt:   v.Record.UpdatedAt,
+			}
+			if err := output.Render(config.Output, data, data.rows(env), nil); err != nil {
+				exitcode.Exit(err)
+			}
+			return
+		}
+		data := varOutput{Key: args[0], Value: value, Layer: v.Layer, Secret: v.Secret}
+		if err := output.Render(config.Output, data, [][]string{{value}}, nil); err != nil {
+			exitcode.Exit(err)
 		}
-		client.GetKey(viper.GetString("env"), args[0])
 	},
 }
 
 func init() {
 	VariablesCmd.AddCommand(getCmd)
+
+	getCmd.Flags().Bool("raw", false, "Show the value without expanding ${...} references")
+	getCmd.Flags().Bool("reveal", false, "Show the value even if it is a secret")
+	getCmd.Flags().Bool("details", false, "Show the description, tags, owner and timestamps too")
 }
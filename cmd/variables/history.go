@@ -0,0 +1,137 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package variables
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/secret"
+)
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history <key>",
+	Short: "Show the revisions of a variable",
+	Long: `Lists every recorded change of a variable, oldest first. Values of
+secrets are masked unless --reveal is passed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireEnv()
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		ws, err := config.GetWorkspace(viper.GetString("workspace"))
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		isSecret, err := resolve.IsSecret(ws.Name, viper.GetString("env"), args[0])
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		client, err := db.NewReadOnlyClient(ws, "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+		revisions, err := client.History(viper.GetString("env"), args[0])
+		client.Close()
+		if err != nil {
+			exitcode.Exit(err)
+		}
+		if len(revisions) == 0 {
+			exitcode.Exit(fmt.Errorf("%w: no history recorded for %s in environment %s", db.ErrKeyNotFound, args[0], viper.GetString("env")))
+		}
+
+		rows := make([][]string, 0, len(revisions))
+		for i, revision := range revisions {
+			revision.Value = secret.Show(revision.Value, isSecret, reveal)
+			revisions[i] = revision
+			rows = append(rows, []string{
+				strconv.Itoa(revision.Rev),
+				formatTimestamp(revision.Timestamp),
+				revision.Author,
+				revision.Operation,
+				revision.Value,
+			})
+		}
+		err = output.Render(config.Output, revisions, rows, func() error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "REV\tTIMESTAMP\tAUTHOR\tOPERATION\tVALUE")
+			for _, row := range rows {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", row[0], row[1], row[2], row[3], row[4])
+			}
+			return w.Flush()
+		})
+		if err != nil {
+			exitcode.Exit(err)
+		}
+	},
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <key>",
+	Short: "Restore a variable to an earlier revision",
+	Long: `Sets a variable back to the value it had at the given revision. The
+rollback is recorded as a new revision so it can be undone as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireEnv()
+		rev, _ := cmd.Flags().GetInt("to")
+		if rev <= 0 {
+			exitcode.Exit(fmt.Errorf("Revision not set! Use --to <rev>, see ryuk var history."))
+		}
+
+		client, err := db.OpenWorkspace(viper.GetString("workspace"), "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+		_, err = client.Rollback(viper.GetString("env"), args[0], rev)
+		client.Close()
+		if err != nil {
+			exitcode.Fatal("Rollback failed", err)
+		}
+		log.Info("Rolled back", "key", args[0], "rev", rev)
+	},
+}
+
+func init() {
+	VariablesCmd.AddCommand(historyCmd, rollbackCmd)
+
+	historyCmd.Flags().Bool("reveal", false, "Show the values of secrets")
+	rollbackCmd.Flags().Int("to", 0, "Revision to restore")
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package variables
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/envfile"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+)
+
+func printSummary(label string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Printf("%s (%d): %s\n", label, len(keys), strings.Join(keys, ", "))
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import variables from a dotenv file",
+	Long: `Reads a dotenv file and stores every variable in the selected environment.
+All variables are written in a single transaction. By default the import is
+aborted if a variable already exists with a different value; use --overwrite
+or --skip-existing to choose how conflicts are handled.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireEnv()
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		skipExisting, _ := cmd.Flags().GetBool("skip-existing")
+		mode := db.ConflictFail
+		if overwrite {
+			mode = db.ConflictOverwrite
+		}
+		if skipExisting {
+			mode = db.ConflictSkip
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			exitcode.Fatal("Error reading file", err)
+		}
+		pairs, err := envfile.Parse(string(data))
+		if err != nil {
+			exitcode.Fatal("Error parsing file", err, "file", args[0])
+		}
+		configs := make([]db.Config, 0, len(pairs))
+		for _, pair := range pairs {
+			configs = append(configs, db.Config{Key: []byte(pair.Key), Value: []byte(pair.Value)})
+		}
+
+		client, err := db.OpenWorkspace(viper.GetString("workspace"), "")
+		if err != nil {
+			exitcode.Fatal("Error opening DB", err)
+		}
+		summary, err := client.ImportKeys(viper.GetString("env"), configs, mode)
+		client.Close()
+		if err != nil {
+			exitcode.Fatal("Import failed", err)
+		}
+
+		printSummary("Added", summary.Added)
+		printSummary("Changed", summary.Changed)
+		printSummary("Unchanged", summary.Unchanged)
+		printSummary("Skipped", summary.Skipped)
+	},
+}
+
+func init() {
+	VariablesCmd.AddCommand(importCmd)
+
+	importCmd.Flags().Bool("overwrite", false, "Replace existing variables with the imported values")
+	importCmd.Flags().Bool("skip-existing", false, "Keep existing variables and only add new ones")
+	importCmd.MarkFlagsMutuallyExclusive("overwrite", "skip-existing")
+}
@@ -22,8 +22,7 @@ THE SOFTWARE.
 package variables
 
 import (
-	"os"
-	"path/filepath"
+	"sort"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -35,11 +34,54 @@ import (
 
 	"github.com/Brian-Kariu/ryuk/config"
 	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/secret"
 )
 
 type Var struct {
-	key string
-	val string
+	key   string
+	val   string
+	layer string
+}
+
+// varOutput is how a variable is rendered by --output json and yaml.
+type varOutput struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+	// Layer is the environment the value was read from.
+	Layer string `json:"layer" yaml:"layer"`
+	// Inherited is set when the value comes from a parent environment or
+	// the global variables.
+	Inherited   bool     `json:"inherited" yaml:"inherited"`
+	Secret      bool     `json:"secret" yaml:"secret"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Error is why the references in the value could not be expanded. The
+	// value is shown unexpanded then.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// hasTags reports whether r is tagged with every one of tags.
+func hasTags(r db.Record, tags []string) bool {
+	for _, tag := range tags {
+		if !r.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// layerLabel describes where a variable of env was read from.
+func layerLabel(layer, env string) string {
+	if layer == db.GlobalBucket {
+		layer = "global"
+	}
+	if layer == env {
+		return layer
+	}
+	return layer + " (inherited)"
 }
 
 var baseStyle = lipgloss.NewStyle().
@@ -86,55 +128,106 @@ var listCmd = &cobra.Command{
 	could be a workspace, environment or variable
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := db.NewClient(filepath.Join(config.BasePath, viper.GetString("workspace")), viper.GetString("env"))
-		if err != nil {
-			log.Error("Error creating DB!")
+		requireEnv()
+		raw, _ := cmd.Flags().GetBool("raw")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		env := viper.GetString("env")
+		var envVars map[string]db.ResolvedVar
+		failed := map[string]error{}
+		var err error
+		if raw {
+			envVars, err = resolve.Load(viper.GetString("workspace"), env, true)
+		} else {
+			envVars, failed, err = resolve.LoadEach(viper.GetString("workspace"), env)
 		}
-		envVars, err := client.ListVars(viper.GetString("env"))
 		if err != nil {
-			log.Fatal(err)
+			exitcode.Exit(err)
 		}
-		columns := []table.Column{
-			{Title: "Key", Width: 40},
-			{Title: "Value", Width: 40},
-		}
-		vars := []Var{}
-		for _, ws := range maps.Keys(envVars) {
-			key := ws
-			value := envVars[ws]
-			vars = append(vars, Var{key: key, val: value})
+
+		for key, v := range envVars {
+			if !hasTags(v.Record, tags) {
+				delete(envVars, key)
+			}
 		}
-		individualRows := make([]table.Row, len(vars)) // Preallocate rows slice
-		for i, env := range vars {
-			individualRows[i] = table.Row{env.key, env.val}
+		keys := maps.Keys(envVars)
+		sort.Strings(keys)
+		data := make([]varOutput, 0, len(keys))
+		rows := make([][]string, 0, len(keys))
+		for _, key := range keys {
+			v := envVars[key]
+			v.Value = secret.Show(v.Value, v.Secret, reveal)
+			envVars[key] = v
+			var message string
+			if err := failed[key]; err != nil {
+				message = err.Error()
+				log.Warn("Unable to expand variable, showing its raw value", "key", key, "err", err)
+			}
+			data = append(data, varOutput{
+				Key:         key,
+				Value:       v.Value,
+				Layer:       v.Layer,
+				Inherited:   v.Layer != env,
+				Secret:      v.Secret,
+				Description: v.Record.Description,
+				Tags:        v.Record.Tags,
+				Error:       message,
+			})
+			rows = append(rows, []string{key, v.Value, layerLabel(v.Layer, env)})
 		}
-		t := table.New(
-			table.WithColumns(columns),
-			table.WithRows(individualRows),
-			table.WithFocused(true),
-			table.WithHeight(7),
-		)
-
-		s := table.DefaultStyles()
-		s.Header = s.Header.
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")).
-			BorderBottom(true).
-			Bold(false)
-		s.Selected = s.Selected.
-			Foreground(lipgloss.Color("229")).
-			Background(lipgloss.Color("57")).
-			Bold(false)
-		t.SetStyles(s)
-
-		m := model{t}
-		if _, err := tea.NewProgram(m).Run(); err != nil {
-			log.Error("Error running program:", err)
-			os.Exit(1)
+		err = output.Render(config.Output, data, rows, func() error {
+			return runVarTable(keys, envVars, env)
+		})
+		if err != nil {
+			exitcode.Exit(err)
 		}
 	},
 }
 
+func runVarTable(keys []string, envVars map[string]db.ResolvedVar, env string) error {
+	columns := []table.Column{
+		{Title: "Key", Width: 40},
+		{Title: "Value", Width: 40},
+		{Title: "Layer", Width: 30},
+	}
+	vars := []Var{}
+	for _, ws := range keys {
+		key := ws
+		value := envVars[ws]
+		vars = append(vars, Var{key: key, val: value.Value, layer: layerLabel(value.Layer, env)})
+	}
+	individualRows := make([]table.Row, len(vars)) // Preallocate rows slice
+	for i, env := range vars {
+		individualRows[i] = table.Row{env.key, env.val, env.layer}
+	}
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(individualRows),
+		table.WithFocused(true),
+		table.WithHeight(7),
+	)
+
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderBottom(true).
+		Bold(false)
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color("229")).
+		Background(lipgloss.Color("57")).
+		Bold(false)
+	t.SetStyles(s)
+
+	m := model{t}
+	_, err := tea.NewProgram(m).Run()
+	return err
+}
+
 func init() {
 	VariablesCmd.AddCommand(listCmd)
+
+	listCmd.Flags().Bool("raw", false, "Show values without expanding ${...} references")
+	listCmd.Flags().Bool("reveal", false, "Show the values of secrets")
+	listCmd.Flags().StringSlice("tag", []string{}, "Only list variables with this tag, can be repeated")
 }
@@ -0,0 +1,23 @@
+package variables
+
+import (
+	"testing"
+
+	"github.com/Brian-Kariu/ryuk/db"
+)
+
+func TestLayerLabel(t *testing.T) {
+	tests := []struct {
+		layer, env string
+		want       string
+	}{
+		{"prod", "prod", "prod"},
+		{"base", "prod", "base (inherited)"},
+		{db.GlobalBucket, "prod", "global (inherited)"},
+	}
+	for _, tt := range tests {
+		if got := layerLabel(tt.layer, tt.env); got != tt.want {
+			t.Errorf("layerLabel(%q, %q) = %q, want %q", tt.layer, tt.env, got, tt.want)
+		}
+	}
+}
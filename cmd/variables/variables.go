@@ -22,10 +22,14 @@ THE SOFTWARE.
 package variables
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
 )
 
 // variablesCmd represents the environment command
@@ -35,12 +39,28 @@ var VariablesCmd = &cobra.Command{
 	Long:  `Manage the various variables for your projects.`,
 }
 
+// requireEnv stops the command when no env was selected, either with
+// --env or through the project file.
+func requireEnv() {
+	if viper.GetString("env") == "" {
+		exitcode.Exit(fmt.Errorf("Env flag not set! Pass --env or select one with ryuk use."))
+	}
+}
+
+// targetBucket returns the bucket written by commands with a --global flag:
+// the global bucket with --global and the selected env otherwise.
+func targetBucket(cmd *cobra.Command) string {
+	if global, _ := cmd.Flags().GetBool("global"); global {
+		return db.GlobalBucket
+	}
+	requireEnv()
+	return viper.GetString("env")
+}
+
 func init() {
 	VariablesCmd.PersistentFlags().StringVarP(&config.CurrentWorkspace, "workspace", "w", "default", "Workspace currently in use.")
 	viper.BindPFlag("workspace", VariablesCmd.PersistentFlags().Lookup("workspace"))
 
-	VariablesCmd.MarkPersistentFlagRequired("workspace")
 	VariablesCmd.PersistentFlags().StringVarP(&config.CurrentEnv, "env", "e", "", "Env currently in use.")
 	viper.BindPFlag("env", VariablesCmd.PersistentFlags().Lookup("env"))
-	VariablesCmd.MarkPersistentFlagRequired("env")
 }
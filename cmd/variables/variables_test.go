@@ -0,0 +1,34 @@
+package variables
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/Brian-Kariu/ryuk/db"
+)
+
+func TestTargetBucket(t *testing.T) {
+	env := viper.GetString("env")
+	viper.Set("env", "dev")
+	t.Cleanup(func() { viper.Set("env", env) })
+
+	for _, cmd := range []string{"set", "delete"} {
+		c, _, err := VariablesCmd.Find([]string{cmd})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(cmd, func(t *testing.T) {
+			t.Cleanup(func() { c.Flags().Set("global", "false") })
+			if got := targetBucket(c); got != "dev" {
+				t.Errorf("targetBucket = %q, want dev", got)
+			}
+			if err := c.Flags().Set("global", "true"); err != nil {
+				t.Fatal(err)
+			}
+			if got := targetBucket(c); got != db.GlobalBucket {
+				t.Errorf("targetBucket with --global = %q, want %q", got, db.GlobalBucket)
+			}
+		})
+	}
+}
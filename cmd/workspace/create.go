@@ -22,60 +22,104 @@ THE SOFTWARE.
 package workspace
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/charmbracelet/huh"
-	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 
+	"github.com/Brian-Kariu/ryuk/cmd/flags"
 	"github.com/Brian-Kariu/ryuk/config"
 	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
 )
 
 // TODO: This should be a standalone func that can be reusable
 // FIX: This might also be okay since its only used here
-func createDb(dbName, description, dbConfigs string, confirm bool) {
-	db.NewClient(filepath.Join(config.BasePath, dbName), dbConfigs)
-	config.NewWorkspaceConfig(dbName, description, []string{}, confirm)
+func createDb(dbName, description, dbConfigs, projectPath, backend string) {
+	if _, err := config.GetWorkspace(dbName); err == nil {
+		exitcode.Exit(fmt.Errorf("Workspace '%s' already exists.", dbName))
+	}
+	if err := db.ValidateBackend(backend); err != nil {
+		exitcode.Exit(err)
+	}
+	ws := config.WorkspaceConfig{DB: filepath.Join(config.BasePath, dbName), Backend: backend}
+	client, err := db.NewClient(ws, dbConfigs)
+	if err != nil {
+		exitcode.Fatal("Error creating DB", err)
+	}
+	client.Close()
+	if err := config.NewWorkspaceConfig(dbName, description, []string{}, projectPath, backend); err != nil {
+		exitcode.Exit(err)
+	}
 }
 
 var createCmd = &cobra.Command{
-	Use:   "create",
-	Args:  cobra.NoArgs,
+	Use:   "create [name]",
+	Args:  cobra.MaximumNArgs(1),
 	Short: "Create a new workspace",
 	Long: `Use this command to create a new resource. A resource
 	could be a workspace
+
+	The name, description and project path can be passed as arguments and
+	flags. Missing values are asked for interactively when running in a
+	terminal.
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var workspaceName string
-		var description string
-		var confirm bool
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewInput().
-					Title("Input workspace Name").
-					Placeholder("workspace").
-					Value(&workspaceName),
-				huh.NewInput().
-					Title("Input short description").
-					Placeholder("description").
-					Value(&description),
-				huh.NewConfirm().
-					Title("Add current path as project path? (You can also input the path manually)").
-					Affirmative("Yes!").
-					Negative("No.").
-					Value(&confirm),
-			),
-		)
-		err := form.Run()
+		description, _ := cmd.Flags().GetString("description")
+		projectPath, _ := cmd.Flags().GetString("project-path")
+		backend, _ := cmd.Flags().GetString("backend")
+		if len(args) == 1 {
+			workspaceName = args[0]
+		}
+
+		if workspaceName == "" {
+			if !flags.CanPrompt() {
+				exitcode.Exit(fmt.Errorf("Workspace name not set!"))
+			}
+			var confirm bool
+			form := huh.NewForm(
+				huh.NewGroup(
+					huh.NewInput().
+						Title("Input workspace Name").
+						Placeholder("workspace").
+						Value(&workspaceName),
+					huh.NewInput().
+						Title("Input short description").
+						Placeholder("description").
+						Value(&description),
+					huh.NewConfirm().
+						Title("Add current path as project path? (You can also input the path manually)").
+						Affirmative("Yes!").
+						Negative("No.").
+						Value(&confirm),
+				),
+			)
+			if err := form.Run(); err != nil {
+				exitcode.Exit(fmt.Errorf("Workspace name not set!"))
+			}
+			if confirm && projectPath == "" {
+				projectPath = "."
+			}
+		}
+		if workspaceName == "" {
+			exitcode.Exit(fmt.Errorf("Workspace name not set!"))
+		}
+
 		dbConfigs, err := cmd.Flags().GetString("config")
 		if err != nil {
-			log.Fatal("DB Config name is not valid")
+			exitcode.Exit(fmt.Errorf("DB Config name is not valid"))
 		}
-		createDb(workspaceName, description, dbConfigs, confirm)
+		createDb(workspaceName, description, dbConfigs, projectPath, backend)
 	},
 }
 
 func init() {
 	WorkspaceCmd.AddCommand(createCmd)
+
+	createCmd.Flags().StringP("description", "d", "", "Short description of the workspace")
+	createCmd.Flags().StringP("project-path", "p", "", "Path of the project using this workspace")
+	createCmd.Flags().String("backend", db.BackendBolt, "Storage backend: "+strings.Join(db.Backends, "|"))
 }
@@ -22,46 +22,92 @@ THE SOFTWARE.
 package workspace
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
+	"github.com/Brian-Kariu/ryuk/cmd/flags"
 	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete",
+	Use:   "delete [name]",
 	Short: "Removes a workspace",
-	Long:  `Deletes the specified workspace. This is case sensitive.`,
+	Long: `Deletes the specified workspace. This is case sensitive.
+
+The workspace database is moved to the trash, where it is kept for the
+trash_retention set in the config file (30 days by default) and can be
+brought back with ryuk workspace restore. When no name is given the
+workspace is picked interactively.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var selected string
-		var opt []huh.Option[string]
-		for _, ws := range config.Workspaces {
-			name := ws.Name
-			id := ws.ID
-			opt = append(opt, huh.NewOption(name, id))
+		if len(args) == 1 {
+			ws, err := config.GetWorkspace(args[0])
+			if err != nil {
+				exitcode.Exit(err)
+			}
+			selected = ws.ID
 		}
 
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Select workspace to delete").
-					Value(&selected).
-					Height(8).
-					Options(opt...),
-			),
-		)
-		err := form.Run()
+		if selected == "" {
+			if !flags.CanPrompt() {
+				exitcode.Exit(fmt.Errorf("Workspace name not set!"))
+			}
+			var opt []huh.Option[string]
+			for _, ws := range config.Workspaces {
+				name := ws.Name
+				id := ws.ID
+				opt = append(opt, huh.NewOption(name, id))
+			}
+
+			form := huh.NewForm(
+				huh.NewGroup(
+					huh.NewSelect[string]().
+						Title("Select workspace to delete").
+						Value(&selected).
+						Height(8).
+						Options(opt...),
+				),
+			)
+			err := form.Run()
+			if err != nil {
+				exitcode.Fatal("Error with selection", err)
+			}
+		}
+		var ws config.WorkspaceConfig
+		for _, w := range config.Workspaces {
+			if w.ID == selected {
+				ws = w
+			}
+		}
+		if ws.ID == "" {
+			exitcode.Exit(fmt.Errorf("%w: %s", config.ErrWorkspaceNotFound, selected))
+		}
+
+		entry, err := config.TrashWorkspace(ws, db.MoveWorkspace)
 		if err != nil {
-			log.Fatal("Error with selection: %v", err)
+			exitcode.Fatal("Error deleting workspace", err)
 		}
-		config.DeleteWorkspace(selected)
-		log.Info("Deleted workspace %v.", "selected", selected)
+		permanent, _ := cmd.Flags().GetBool("permanent")
+		if permanent {
+			if err := config.PurgeEntry(entry); err != nil {
+				exitcode.Fatal("Error deleting workspace", err)
+			}
+			log.Info("Permanently deleted workspace", "name", ws.Name)
+			return
+		}
+		log.Info("Moved workspace to trash", "name", ws.Name, "restore", "ryuk workspace restore "+ws.Name)
+		purgeExpired()
 	},
 }
 
 func init() {
 	WorkspaceCmd.AddCommand(deleteCmd)
-	viper.BindPFlags(createCmd.Flags())
+
+	deleteCmd.Flags().Bool("permanent", false, "Delete the workspace data instead of moving it to the trash")
 }
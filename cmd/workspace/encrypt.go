@@ -0,0 +1,71 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package workspace
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+)
+
+func encryptWorkspace(ws config.WorkspaceConfig) {
+	client, err := db.NewClient(ws, "")
+	if err != nil {
+		exitcode.Fatal("Error opening DB", err, "workspace", ws.Name)
+	}
+	sealed, err := client.EncryptAll()
+	client.Close()
+	if err != nil {
+		exitcode.Fatal("Error encrypting workspace", err, "workspace", ws.Name)
+	}
+	log.Info("Encrypted workspace", "workspace", ws.Name, "values", sealed)
+}
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt [workspace]",
+	Short: "Encrypt plaintext values in a workspace",
+	Long: `Seals every value that was stored in plaintext by older versions of ryuk.
+Values that are already encrypted are left untouched. When no workspace is
+given every workspace is migrated.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			ws, err := config.GetWorkspace(args[0])
+			if err != nil {
+				exitcode.Exit(err)
+			}
+			encryptWorkspace(ws)
+			return
+		}
+
+		for _, ws := range config.Workspaces {
+			encryptWorkspace(ws)
+		}
+	},
+}
+
+func init() {
+	WorkspaceCmd.AddCommand(encryptCmd)
+}
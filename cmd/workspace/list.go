@@ -24,16 +24,18 @@ package workspace
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
 )
 
 const (
@@ -87,6 +89,16 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	fmt.Fprint(w, fn(str))
 }
 
+// workspaceOutput is how a workspace is rendered by --output json and yaml.
+type workspaceOutput struct {
+	ID           string   `json:"id" yaml:"id"`
+	Name         string   `json:"name" yaml:"name"`
+	Description  string   `json:"description" yaml:"description"`
+	Project      string   `json:"project" yaml:"project"`
+	DB           string   `json:"db" yaml:"db"`
+	Environments []string `json:"environments" yaml:"environments"`
+}
+
 type model struct {
 	list     list.Model
 	choice   string
@@ -142,23 +154,44 @@ var listCmd = &cobra.Command{
 		if err != nil {
 			fmt.Println("Error fetching workspaces:", err)
 		}
-		if len(config.Workspaces) == 0 {
+		if len(config.Workspaces) == 0 && config.Output == output.Table {
 			fmt.Printf("No workspaces found.\n")
 			return
 		}
 		items := []list.Item{}
+		data := []workspaceOutput{}
+		rows := [][]string{}
 		for _, ws := range config.Workspaces {
 			title := ws.Name
 			desc := ws.Description
 			items = append(items, item{title: title, desc: desc})
+
+			envs := []string{}
+			for env := range ws.Environment {
+				envs = append(envs, env)
+			}
+			sort.Strings(envs)
+			data = append(data, workspaceOutput{
+				ID:           ws.ID,
+				Name:         ws.Name,
+				Description:  ws.Description,
+				Project:      ws.Project,
+				DB:           ws.DB,
+				Environments: envs,
+			})
+			rows = append(rows, []string{ws.Name, ws.Description})
 		}
 
-		l := list.New(items, list.NewDefaultDelegate(), 0, 0)
-		l.Title = "Listing workspaces"
-		m := model{list: l}
+		err = output.Render(config.Output, data, rows, func() error {
+			l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+			l.Title = "Listing workspaces"
+			m := model{list: l}
 
-		if _, err := tea.NewProgram(m).Run(); err != nil {
-			log.Fatal("Error rendering workspaces: ", err)
+			_, err := tea.NewProgram(m).Run()
+			return err
+		})
+		if err != nil {
+			exitcode.Fatal("Error rendering workspaces", err)
 		}
 	},
 }
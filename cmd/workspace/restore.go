@@ -0,0 +1,111 @@
+/*
+Copyright © 2024 Brian Kariu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/exitcode"
+	"github.com/Brian-Kariu/ryuk/internal/output"
+)
+
+// purgeExpired permanently removes workspaces that outlived the trash
+// retention window.
+func purgeExpired() {
+	purged, err := config.PurgeTrash(config.TrashRetention())
+	if err != nil {
+		log.Warn("Error emptying trash", "err", err)
+	}
+	for _, entry := range purged {
+		log.Info("Purged workspace from trash", "name", entry.Workspace.Name, "deleted", entry.DeletedAt.Local().Format(time.RFC3339))
+	}
+}
+
+// trashOutput is how a trashed workspace is rendered by --output json and yaml.
+type trashOutput struct {
+	Name      string    `json:"name" yaml:"name"`
+	ID        string    `json:"id" yaml:"id"`
+	DeletedAt time.Time `json:"deleted_at" yaml:"deleted_at"`
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+}
+
+func listTrash() {
+	entries, err := config.ListTrash()
+	if err != nil {
+		exitcode.Fatal("Error reading trash", err)
+	}
+	retention := config.TrashRetention()
+	data := []trashOutput{}
+	rows := [][]string{}
+	for _, entry := range entries {
+		expires := entry.DeletedAt.Add(retention)
+		data = append(data, trashOutput{Name: entry.Workspace.Name, ID: entry.Workspace.ID, DeletedAt: entry.DeletedAt, ExpiresAt: expires})
+		rows = append(rows, []string{entry.Workspace.Name, entry.DeletedAt.Local().Format(time.RFC3339), expires.Local().Format(time.RFC3339)})
+	}
+	err = output.Render(config.Output, data, rows, func() error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tDELETED\tEXPIRES")
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", row[0], row[1], row[2])
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		exitcode.Exit(err)
+	}
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [name]",
+	Short: "Restores a deleted workspace",
+	Long: `Brings a deleted workspace back from the trash, including its data and
+config entry. Use --list to see what is in the trash.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		purgeExpired()
+		list, _ := cmd.Flags().GetBool("list")
+		if list || len(args) == 0 {
+			listTrash()
+			return
+		}
+
+		ws, err := config.RestoreWorkspace(args[0], db.MoveWorkspace)
+		if err != nil {
+			exitcode.Fatal("Error restoring workspace", err)
+		}
+		log.Info("Restored workspace", "name", ws.Name)
+	},
+}
+
+func init() {
+	WorkspaceCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().Bool("list", false, "List the workspaces in the trash")
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/google/uuid"
@@ -14,58 +16,65 @@ var (
 	Workspaces       []WorkspaceConfig
 	CurrentWorkspace string
 	CurrentEnv       string
+	// Output is the format selected with the global --output flag.
+	Output string
 )
 
 func updateWorkspaces(w WorkspaceConfig) error {
 	err := checkWorkspaceExists(w.Name)
 	if err != nil {
-		fmt.Errorf("Workspace config: %s\n", err)
+		return fmt.Errorf("Workspace config: %s", err)
 	}
 
 	Workspaces = append(Workspaces, w)
-	viper.Set("workspaces", Workspaces)
-	if err := viper.WriteConfig(); err != nil {
-		fmt.Errorf("Error saving workspaces : %v\n", err)
+	if err := saveWorkspaces(); err != nil {
+		Workspaces = Workspaces[:len(Workspaces)-1]
+		return err
 	}
 	return nil
 }
 
-// TODO: Link environment struct to this
 type WorkspaceConfig struct {
-	ID          string              `mapstructure:"id"`
-	Name        string              `mapstructure:"name"`
-	DB          string              `mapstructure:"db"`
-	Description string              `mapstructure:"description"`
-	Project     string              `mapstructure:"project"`
-	Environment map[string]struct{} `mapstructure:"environment"`
+	ID          string                       `mapstructure:"id"`
+	Name        string                       `mapstructure:"name"`
+	DB          string                       `mapstructure:"db"`
+	Description string                       `mapstructure:"description"`
+	Project     string                       `mapstructure:"project"`
+	Environment map[string]EnvironmentConfig `mapstructure:"environment"`
+	// Backend is the storage engine of the workspace; empty means bolt.
+	Backend string `mapstructure:"backend"`
 }
 
 func DeleteWorkspace(id string) {
+	values := map[string]interface{}{}
 	for i, ws := range Workspaces {
 		if ws.ID == id {
 			Workspaces = append(Workspaces[:i], Workspaces[i+1:]...)
+			if SavedContext().Workspace == ws.Name {
+				values["context"] = Context{}
+			}
 			break
 		}
 	}
-	viper.Set("workspaces", Workspaces)
-	if err := viper.WriteConfig(); err != nil {
-		fmt.Errorf("Error saving workspaces : %v\n", err)
+	values["workspaces"] = Workspaces
+	if err := writeConfig(values); err != nil {
+		log.Error("Error saving workspaces", "err", err)
 	}
 }
 
 func UpdateWorkspace(name, env string) {
 	currentWorkspaceIndex := 0
 	ws, err := GetWorkspace(name)
-	envSet := map[string]struct{}{}
+	envSet := map[string]EnvironmentConfig{}
 	if err != nil {
-		fmt.Println("Error fetching workspace, %s", err)
+		log.Error("Error fetching workspace", "err", err)
 	}
 	if len(ws.Environment) == 0 {
-		envSet[env] = struct{}{}
+		envSet[env] = EnvironmentConfig{}
 		ws.Environment = envSet
 	}
-	if len(ws.Environment) != 0 {
-		ws.Environment[env] = struct{}{}
+	if _, ok := ws.Environment[env]; !ok {
+		ws.Environment[env] = EnvironmentConfig{}
 	}
 	for i, cw := range Workspaces {
 		if ws.ID == cw.ID {
@@ -73,21 +82,79 @@ func UpdateWorkspace(name, env string) {
 		}
 	}
 	Workspaces[currentWorkspaceIndex].Environment = ws.Environment
-	log.Info("Current env var: %s", "value", ws.Environment)
-	log.Info("Current workspaces: %s", "value", Workspaces[0].Name)
-	viper.Set("workspaces", Workspaces)
-	if err := viper.WriteConfig(); err != nil {
-		log.Error("Error saving workspaces : %v\n", err)
+	log.Debug("Updated workspace environments", "workspace", ws.Name, "environments", len(ws.Environment))
+	if err := saveWorkspaces(); err != nil {
+		log.Error(err)
 	}
 }
 
+// RemoveEnvironment drops env from the workspace config.
+func RemoveEnvironment(name, env string) error {
+	for i, ws := range Workspaces {
+		if ws.Name != name {
+			continue
+		}
+		if _, ok := ws.Environment[env]; !ok {
+			return envNotFound(env, name)
+		}
+		if children := ws.Children(env); len(children) > 0 {
+			return fmt.Errorf("Environment %s is extended by %s", env, strings.Join(children, ", "))
+		}
+		delete(Workspaces[i].Environment, env)
+		values := map[string]interface{}{"workspaces": Workspaces}
+		if ctx := SavedContext(); ctx.Workspace == name && ctx.Env == env {
+			values["context"] = Context{Workspace: name}
+		}
+		if err := writeConfig(values); err != nil {
+			return fmt.Errorf("Error saving workspaces : %v", err)
+		}
+		return nil
+	}
+	return workspaceNotFound(name)
+}
+
+// SnapshotDir is where deleted environments of a workspace are kept. It is
+// keyed by ID so snapshots survive renaming the workspace.
+func SnapshotDir(ws WorkspaceConfig) string {
+	return filepath.Join(BasePath, "snapshots", ws.ID)
+}
+
+// DefaultLockTimeout is how long to wait for a workspace held by another
+// process unless lock_timeout is set in the config file.
+const DefaultLockTimeout = 5 * time.Second
+
+// LockTimeout returns how long to wait for a workspace that is locked by
+// another process. A lock_timeout of 0 fails straight away.
+func LockTimeout() time.Duration {
+	return lockTimeout(viper.GetViper())
+}
+
+func lockTimeout(v *viper.Viper) time.Duration {
+	value := v.GetString("lock_timeout")
+	if value == "" {
+		return DefaultLockTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return DefaultLockTimeout
+	}
+	if timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
 func GetWorkspace(name string) (WorkspaceConfig, error) {
-	for _, ws := range Workspaces {
+	return findWorkspace(Workspaces, name)
+}
+
+func findWorkspace(workspaces []WorkspaceConfig, name string) (WorkspaceConfig, error) {
+	for _, ws := range workspaces {
 		if ws.Name == name {
 			return ws, nil
 		}
 	}
-	return WorkspaceConfig{}, fmt.Errorf("Couldn't find workspace ", name)
+	return WorkspaceConfig{}, workspaceNotFound(name)
 }
 
 func checkWorkspaceExists(name string) error {
@@ -98,22 +165,25 @@ func checkWorkspaceExists(name string) error {
 
 	for _, ws := range Workspaces {
 		if ws.Name == name {
-			return fmt.Errorf("Workspace '%s' already exists.\n", name)
+			return fmt.Errorf("Workspace '%s' already exists.", name)
 		}
 	}
 	return nil
 }
 
-func NewWorkspaceConfig(name, description string, environment []string, confirm bool) {
-	var projectPath string
+func NewWorkspaceConfig(name, description string, environment []string, projectPath, backend string) error {
 	filePath := filepath.Join(BasePath, name)
-	if confirm {
-		projectPath, _ = os.Getwd()
+	if projectPath != "" {
+		absPath, err := filepath.Abs(projectPath)
+		if err != nil {
+			return fmt.Errorf("Invalid project path %s: %v", projectPath, err)
+		}
+		projectPath = absPath
 	}
 	id := uuid.New().String()
-	envSet := map[string]struct{}{}
+	envSet := map[string]EnvironmentConfig{}
 	for _, env := range environment {
-		envSet[env] = struct{}{}
+		envSet[env] = EnvironmentConfig{}
 	}
 
 	newWorkspace := WorkspaceConfig{
@@ -123,10 +193,52 @@ func NewWorkspaceConfig(name, description string, environment []string, confirm
 		Description: description,
 		Environment: envSet,
 		Project:     projectPath,
+		Backend:     backend,
 	}
 	err := updateWorkspaces(newWorkspace)
 	if err != nil {
-		log.Error("Error creating workspace %s", name)
+		return err
 	}
 	log.Printf("%s workspace has been created.\n", name)
+	return nil
+}
+
+// MoveFunc moves the database of ws to dst. The CLI passes
+// db.MoveWorkspace, which waits for other processes to release the
+// workspace first.
+type MoveFunc func(ws WorkspaceConfig, dst string) error
+
+// RenameWorkspace renames a workspace and moves its database to match the
+// new name with move. The ID stays the same so snapshots keep working.
+func RenameWorkspace(name, newName string, move MoveFunc) (WorkspaceConfig, error) {
+	if err := checkWorkspaceExists(newName); err != nil {
+		return WorkspaceConfig{}, err
+	}
+	for i, ws := range Workspaces {
+		if ws.Name != name {
+			continue
+		}
+		dbPath := filepath.Join(BasePath, newName)
+		if _, err := os.Stat(dbPath); err == nil {
+			return ws, fmt.Errorf("A database already exists at %s", dbPath)
+		}
+		if err := move(ws, dbPath); err != nil {
+			return ws, fmt.Errorf("Error moving database: %w", err)
+		}
+
+		Workspaces[i].Name = newName
+		Workspaces[i].DB = dbPath
+		values := map[string]interface{}{"workspaces": Workspaces}
+		if ctx := SavedContext(); ctx.Workspace == name {
+			ctx.Workspace = newName
+			values["context"] = ctx
+		}
+		if err := writeConfig(values); err != nil {
+			move(Workspaces[i], ws.DB)
+			Workspaces[i] = ws
+			return ws, fmt.Errorf("Error saving workspaces : %v", err)
+		}
+		return Workspaces[i], nil
+	}
+	return WorkspaceConfig{}, workspaceNotFound(name)
 }
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultWorkspace is used when nothing else selects a workspace.
+	DefaultWorkspace = "default"
+	// WorkspaceEnvVar selects the workspace for a single shell.
+	WorkspaceEnvVar = "RYUK_WORKSPACE"
+	// EnvEnvVar selects the env for a single shell.
+	EnvEnvVar = "RYUK_ENV"
+)
+
+// Context is the workspace and env selected with ryuk use.
+type Context struct {
+	Workspace string `mapstructure:"workspace" yaml:"workspace"`
+	Env       string `mapstructure:"env" yaml:"env,omitempty"`
+}
+
+// SavedContext returns the context stored in the config file.
+func SavedContext() Context {
+	ctx := Context{}
+	viper.UnmarshalKey("context", &ctx)
+	return ctx
+}
+
+// UseContext checks that ctx exists and stores it in the config file.
+func UseContext(ctx Context) error {
+	ws, err := GetWorkspace(ctx.Workspace)
+	if err != nil {
+		return err
+	}
+	if ctx.Env != "" {
+		if err := ws.CheckEnv(ctx.Env); err != nil {
+			return err
+		}
+	}
+	return writeConfig(map[string]interface{}{"context": ctx})
+}
+
+// ContextValue is a workspace or env along with where it was taken from.
+type ContextValue struct {
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source" yaml:"source"`
+}
+
+// Selection is the workspace and env commands run against.
+type Selection struct {
+	Workspace ContextValue `json:"workspace" yaml:"workspace"`
+	Env       ContextValue `json:"env" yaml:"env"`
+}
+
+type contextSource struct {
+	workspace string
+	env       string
+	source    string
+}
+
+// ResolveContext picks the workspace and env for commands run from dir.
+// The first of these that names a workspace wins:
+//
+//   - workspace, as passed with -w
+//   - the RYUK_WORKSPACE and RYUK_ENV variables of the shell
+//   - a project file above dir
+//   - a workspace whose project path holds dir
+//   - the context saved with ryuk use
+//   - the default workspace
+//
+// The env is taken from the same sources, skipping those that name another
+// workspace. A project file that can not be read is skipped and its error
+// is returned along with the selection.
+func ResolveContext(dir, workspace string) (Selection, error) {
+	sources := []contextSource{{workspace: workspace, source: "flag"}}
+	sources = append(sources, contextSource{
+		workspace: os.Getenv(WorkspaceEnvVar),
+		env:       os.Getenv(EnvEnvVar),
+		source:    "shell",
+	})
+
+	project, ok, err := FindProjectFile(dir)
+	if ok {
+		sources = append(sources, contextSource{project.Workspace, project.Env, "project file " + project.Path})
+	} else if ws, ok := WorkspaceForPath(dir); ok {
+		sources = append(sources, contextSource{ws.Name, "", "project path " + ws.Project})
+	}
+
+	saved := SavedContext()
+	sources = append(sources, contextSource{saved.Workspace, saved.Env, "ryuk use"})
+	sources = append(sources, contextSource{DefaultWorkspace, "", "default"})
+
+	selection := Selection{}
+	for _, s := range sources {
+		if s.workspace != "" {
+			selection.Workspace = ContextValue{Value: s.workspace, Source: s.source}
+			break
+		}
+	}
+	for _, s := range sources {
+		if s.env != "" && (s.workspace == "" || s.workspace == selection.Workspace.Value) {
+			selection.Env = ContextValue{Value: s.env, Source: s.source}
+			break
+		}
+	}
+	return selection, err
+}
+
+// legacyKeys were saved to the config file by versions of ryuk that wrote
+// every flag value bound to viper along with the workspaces.
+var legacyKeys = []string{"workspace", "env", "name", "description", "extends", "project-path"}
+
+// writeConfig stores values in the config file. Unlike viper.WriteConfig it
+// starts from the file on disk, so flag values bound to viper are not saved.
+func writeConfig(values map[string]interface{}) error {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		path = filepath.Join(BasePath, ".ryuk.yaml")
+	}
+
+	settings := map[string]interface{}{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error reading config: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("Error reading config: %v", err)
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	for _, key := range legacyKeys {
+		delete(settings, key)
+	}
+	for key, value := range values {
+		settings[key] = value
+		viper.Set(key, value)
+	}
+
+	data, err = yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// saveWorkspaces writes the workspaces to the config file.
+func saveWorkspaces() error {
+	if err := writeConfig(map[string]interface{}{"workspaces": Workspaces}); err != nil {
+		return fmt.Errorf("Error saving workspaces : %v", err)
+	}
+	return nil
+}
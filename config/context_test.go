@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveContext(t *testing.T) {
+	root := t.TempDir()
+	projectFile := filepath.Join(root, "app", ProjectFileName)
+	writeFile(t, projectFile, "workspace: file\nenv: qa\n")
+	nested := filepath.Join(root, "app", "src", "pkg")
+	writeFile(t, filepath.Join(root, "broken", ProjectFileName), "workspace: [")
+	projectPath := filepath.Join(root, "other")
+	inProjectPath := filepath.Join(projectPath, "sub")
+	for _, dir := range []string{nested, inProjectPath} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	used := Context{Workspace: "used", Env: "staging"}
+
+	tests := []struct {
+		name      string
+		dir, flag string
+		shellWS   string
+		shellEnv  string
+		saved     Context
+		want      Selection
+		wantErr   bool
+	}{
+		{
+			name: "flag", dir: nested, flag: "cli", shellEnv: "prod", saved: used,
+			want: Selection{ContextValue{"cli", "flag"}, ContextValue{"prod", "shell"}},
+		},
+		{
+			name: "flag skips envs of other workspaces", dir: nested, flag: "cli", shellWS: "shell", shellEnv: "prod", saved: used,
+			want: Selection{Workspace: ContextValue{"cli", "flag"}},
+		},
+		{
+			name: "shell", dir: nested, shellWS: "shell", shellEnv: "prod", saved: used,
+			want: Selection{ContextValue{"shell", "shell"}, ContextValue{"prod", "shell"}},
+		},
+		{
+			name: "shell env only", dir: nested, shellEnv: "prod", saved: used,
+			want: Selection{ContextValue{"file", "project file " + projectFile}, ContextValue{"prod", "shell"}},
+		},
+		{
+			name: "project file above dir", dir: nested, saved: used,
+			want: Selection{ContextValue{"file", "project file " + projectFile}, ContextValue{"qa", "project file " + projectFile}},
+		},
+		{
+			name: "project path", dir: inProjectPath, saved: used,
+			want: Selection{Workspace: ContextValue{"pathws", "project path " + projectPath}},
+		},
+		{
+			name: "ryuk use", dir: root, saved: used,
+			want: Selection{ContextValue{"used", "ryuk use"}, ContextValue{"staging", "ryuk use"}},
+		},
+		{
+			name: "broken project file is skipped", dir: filepath.Join(root, "broken"), saved: used,
+			want:    Selection{ContextValue{"used", "ryuk use"}, ContextValue{"staging", "ryuk use"}},
+			wantErr: true,
+		},
+		{
+			name: "default", dir: root,
+			want: Selection{Workspace: ContextValue{DefaultWorkspace, "default"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupConfig(t)
+			Workspaces = []WorkspaceConfig{{Name: "pathws", Project: projectPath}}
+			if tt.saved != (Context{}) {
+				viper.Set("context", map[string]interface{}{"workspace": tt.saved.Workspace, "env": tt.saved.Env})
+			}
+			t.Setenv(WorkspaceEnvVar, tt.shellWS)
+			t.Setenv(EnvEnvVar, tt.shellEnv)
+
+			got, err := ResolveContext(tt.dir, tt.flag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveContext returned %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveContext = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	setupConfig(t)
+	path := viper.ConfigFileUsed()
+	writeFile(t, path, "lock_timeout: 2s\ncustom:\n  nested: 1\nworkspace: legacy\nworkspaces: []\n")
+	// Flag values bound to viper are not written to the file.
+	viper.Set("env", "dev")
+
+	if err := writeConfig(map[string]interface{}{"context": Context{Workspace: "app"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"lock_timeout": "2s",
+		"custom":       map[string]interface{}{"nested": 1},
+		"workspaces":   []interface{}{},
+		"context":      map[string]interface{}{"workspace": "app"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("config file = %v, want %v", got, want)
+	}
+	if ctx := SavedContext(); ctx.Workspace != "app" {
+		t.Errorf("SavedContext = %+v, want the written context", ctx)
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EnvironmentConfig holds the settings of a single environment. An
+// environment with a parent inherits every variable it does not define
+// itself.
+type EnvironmentConfig struct {
+	Parent string `mapstructure:"parent" yaml:"parent,omitempty" json:"parent,omitempty"`
+}
+
+// Children returns the environments that directly extend env.
+func (w WorkspaceConfig) Children(env string) []string {
+	children := []string{}
+	for name, e := range w.Environment {
+		if e.Parent == env {
+			children = append(children, name)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// EnvChain returns env followed by its ancestors, closest first.
+func (w WorkspaceConfig) EnvChain(env string) ([]string, error) {
+	chain := []string{}
+	seen := map[string]bool{}
+	for current := env; current != ""; {
+		if seen[current] {
+			return nil, fmt.Errorf("Environment inheritance cycle: %s -> %s", strings.Join(chain, " -> "), current)
+		}
+		e, ok := w.Environment[current]
+		if !ok {
+			if current == env {
+				return nil, envNotFound(env, w.Name)
+			}
+			return nil, fmt.Errorf("Environment %s extends unknown environment %s", chain[len(chain)-1], current)
+		}
+		seen[current] = true
+		chain = append(chain, current)
+		current = e.Parent
+	}
+	return chain, nil
+}
+
+// EnvParents returns the ancestors of env in the named workspace, closest
+// first.
+func EnvParents(name, env string) ([]string, error) {
+	ws, err := GetWorkspace(name)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := ws.EnvChain(env)
+	if err != nil {
+		return nil, err
+	}
+	return chain[1:], nil
+}
+
+// SetParent makes env extend parent. An empty parent removes inheritance.
+func SetParent(name, env, parent string) error {
+	for i, ws := range Workspaces {
+		if ws.Name != name {
+			continue
+		}
+		e, ok := ws.Environment[env]
+		if !ok {
+			return envNotFound(env, name)
+		}
+		if parent != "" {
+			if _, ok := ws.Environment[parent]; !ok {
+				return envNotFound(parent, name)
+			}
+		}
+
+		previous := e.Parent
+		e.Parent = parent
+		Workspaces[i].Environment[env] = e
+		if _, err := Workspaces[i].EnvChain(env); err != nil {
+			e.Parent = previous
+			Workspaces[i].Environment[env] = e
+			return err
+		}
+
+		return saveWorkspaces()
+	}
+	return workspaceNotFound(name)
+}
+
+// RenameEnvironment renames env in the workspace config, pointing the
+// environments that extend it at the new name.
+func RenameEnvironment(name, env, newEnv string) error {
+	for i, ws := range Workspaces {
+		if ws.Name != name {
+			continue
+		}
+		if _, ok := ws.Environment[env]; !ok {
+			return envNotFound(env, name)
+		}
+		if _, ok := ws.Environment[newEnv]; ok {
+			return fmt.Errorf("Environment %s already exists in workspace %s", newEnv, name)
+		}
+
+		environment := map[string]EnvironmentConfig{}
+		for e, c := range ws.Environment {
+			if c.Parent == env {
+				c.Parent = newEnv
+			}
+			if e == env {
+				e = newEnv
+			}
+			environment[e] = c
+		}
+		Workspaces[i].Environment = environment
+		values := map[string]interface{}{"workspaces": Workspaces}
+		if ctx := SavedContext(); ctx.Workspace == name && ctx.Env == env {
+			ctx.Env = newEnv
+			values["context"] = ctx
+		}
+		if err := writeConfig(values); err != nil {
+			Workspaces[i].Environment = ws.Environment
+			return fmt.Errorf("Error saving workspaces : %v", err)
+		}
+		return nil
+	}
+	return workspaceNotFound(name)
+}
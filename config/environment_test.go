@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chainWorkspace has base <- staging <- prod, with dev extending base.
+func chainWorkspace() WorkspaceConfig {
+	return WorkspaceConfig{
+		Name: "app",
+		Environment: map[string]EnvironmentConfig{
+			"base":    {},
+			"dev":     {Parent: "base"},
+			"staging": {Parent: "base"},
+			"prod":    {Parent: "staging"},
+			"orphan":  {Parent: "gone"},
+			"a":       {Parent: "b"},
+			"b":       {Parent: "a"},
+		},
+	}
+}
+
+func TestEnvChain(t *testing.T) {
+	ws := chainWorkspace()
+	tests := []struct {
+		env     string
+		want    []string
+		wantErr string
+	}{
+		{env: "base", want: []string{"base"}},
+		{env: "dev", want: []string{"dev", "base"}},
+		{env: "prod", want: []string{"prod", "staging", "base"}},
+		{env: "missing", wantErr: "missing"},
+		{env: "orphan", wantErr: "Environment orphan extends unknown environment gone"},
+		{env: "a", wantErr: "Environment inheritance cycle: a -> b -> a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			got, err := ws.EnvChain(tt.env)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("EnvChain(%s) returned %v, want %q", tt.env, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EnvChain(%s) = %v, want %v", tt.env, got, tt.want)
+			}
+		})
+	}
+	if _, err := ws.EnvChain("missing"); !errors.Is(err, ErrEnvNotFound) {
+		t.Errorf("EnvChain(missing) returned %v, want %v", err, ErrEnvNotFound)
+	}
+	if got, want := ws.Children("base"), []string{"dev", "staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Children(base) = %v, want %v", got, want)
+	}
+}
+
+func TestSetParent(t *testing.T) {
+	setupConfig(t)
+	ws := chainWorkspace()
+	delete(ws.Environment, "orphan")
+	delete(ws.Environment, "a")
+	delete(ws.Environment, "b")
+	Workspaces = []WorkspaceConfig{ws}
+
+	tests := []struct {
+		name, env, parent string
+		wantErr           string
+	}{
+		{"self", "base", "base", "cycle"},
+		{"descendant", "base", "prod", "Environment inheritance cycle: base -> prod -> staging -> base"},
+		{"unknown parent", "dev", "qa", "qa"},
+		{"unknown environment", "qa", "base", "qa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetParent("app", tt.env, tt.parent)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("SetParent(%s, %s) returned %v, want %q", tt.env, tt.parent, err, tt.wantErr)
+			}
+		})
+	}
+	// Rejected parents are not kept.
+	if got := Workspaces[0].Environment["base"].Parent; got != "" {
+		t.Errorf("base extends %q after a rejected SetParent", got)
+	}
+
+	if err := SetParent("app", "dev", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := EnvParents("app", "dev"); err != nil || !reflect.DeepEqual(got, []string{"prod", "staging", "base"}) {
+		t.Errorf("EnvParents(dev) = %v, %v", got, err)
+	}
+	if err := SetParent("app", "dev", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := EnvParents("app", "dev"); err != nil || len(got) != 0 {
+		t.Errorf("EnvParents(dev) after clearing = %v, %v", got, err)
+	}
+	if err := SetParent("other", "dev", "base"); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Errorf("SetParent in a missing workspace returned %v, want %v", err, ErrWorkspaceNotFound)
+	}
+}
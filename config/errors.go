@@ -0,0 +1,28 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrEnvNotFound       = errors.New("environment not found")
+)
+
+func workspaceNotFound(name string) error {
+	return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, name)
+}
+
+func envNotFound(env, workspace string) error {
+	return fmt.Errorf("%w: %s in workspace %s", ErrEnvNotFound, env, workspace)
+}
+
+// CheckEnv returns an error wrapping ErrEnvNotFound when env is not an
+// environment of the workspace.
+func (w WorkspaceConfig) CheckEnv(env string) error {
+	if _, ok := w.Environment[env]; !ok {
+		return envNotFound(env, w.Name)
+	}
+	return nil
+}
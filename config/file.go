@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// File is a config file loaded by a program that uses ryuk as a library.
+// It keeps its own viper instance and workspaces, so loading it leaves the
+// global state used by the CLI, and by the program itself, alone.
+type File struct {
+	BasePath   string
+	Workspaces []WorkspaceConfig
+	v          *viper.Viper
+	// key replaces the key file kept in BasePath when set.
+	key []byte
+}
+
+// LoadFile reads the config file kept in basePath, ~/.ryuk when empty.
+func LoadFile(basePath string) (*File, error) {
+	if basePath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		basePath = filepath.Join(home, ".ryuk/")
+	}
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(basePath, ".ryuk.yaml"))
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("Error reading config: %v", err)
+	}
+	f := &File{BasePath: basePath, v: v}
+	if err := v.UnmarshalKey("workspaces", &f.Workspaces); err != nil {
+		return nil, fmt.Errorf("Error reading config: %v", err)
+	}
+	return f, nil
+}
+
+// GlobalFile returns the config loaded into the package state by the CLI.
+func GlobalFile() *File {
+	return &File{BasePath: BasePath, Workspaces: Workspaces, v: viper.GetViper()}
+}
+
+// NewFile returns a File holding workspaces that is not read from disk.
+// Values are sealed with key and the default settings apply.
+func NewFile(workspaces []WorkspaceConfig, key []byte) *File {
+	return &File{Workspaces: workspaces, v: viper.New(), key: key}
+}
+
+func (f *File) GetWorkspace(name string) (WorkspaceConfig, error) {
+	return findWorkspace(f.Workspaces, name)
+}
+
+// LockTimeout is LockTimeout for the settings of f.
+func (f *File) LockTimeout() time.Duration {
+	return lockTimeout(f.v)
+}
+
+// EncryptionKey is EncryptionKey for the key kept next to f.
+func (f *File) EncryptionKey() ([]byte, error) {
+	if f.key != nil {
+		return f.key, nil
+	}
+	return encryptionKey(f.BasePath)
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// PassphraseEnv holds a passphrase used to derive the encryption key.
+	// When it is unset the key file under BasePath is used instead.
+	PassphraseEnv = "RYUK_PASSPHRASE"
+	keyFileName   = "ryuk.key"
+	saltFileName  = "ryuk.salt"
+	keySize       = 32
+	saltSize      = 16
+)
+
+// EncryptionKey returns the key used to seal variable values. It is derived
+// from RYUK_PASSPHRASE when set, otherwise read from (or created in) the key
+// file stored next to the workspace databases.
+func EncryptionKey() ([]byte, error) {
+	return encryptionKey(BasePath)
+}
+
+func encryptionKey(basePath string) ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		salt, err := readOrCreate(filepath.Join(basePath, saltFileName), saltSize)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading key salt: %v", err)
+		}
+		return argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, keySize), nil
+	}
+
+	key, err := readOrCreate(filepath.Join(basePath, keyFileName), keySize)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading key file: %v", err)
+	}
+	return key, nil
+}
+
+// readOrCreate returns the contents of path, filling it with size random
+// bytes first if it does not exist yet.
+func readOrCreate(path string, size int) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if len(data) != size {
+			return nil, fmt.Errorf("%s is corrupt: expected %d bytes, got %d", path, size, len(data))
+		}
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	data = make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		// Another ryuk process created it first.
+		return readOrCreate(path, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the file that ties a directory tree to a workspace.
+//
+//	workspace: myapp
+//	env: dev
+const ProjectFileName = ".ryuk"
+
+// ProjectConfig is the content of a project file.
+type ProjectConfig struct {
+	Workspace string `yaml:"workspace"`
+	Env       string `yaml:"env"`
+	// Path of the file the config was read from.
+	Path string `yaml:"-"`
+}
+
+// FindProjectFile walks up from dir looking for a project file. It returns
+// false when there is none. Directories called .ryuk, such as the one in the
+// home directory, are skipped.
+func FindProjectFile(dir string) (ProjectConfig, bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ProjectConfig{}, false, err
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			project, err := readProjectFile(path)
+			return project, err == nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ProjectConfig{}, false, nil
+		}
+		dir = parent
+	}
+}
+
+func readProjectFile(path string) (ProjectConfig, error) {
+	project := ProjectConfig{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return project, err
+	}
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return project, fmt.Errorf("invalid project file %s: %v", path, err)
+	}
+	return project, nil
+}
+
+// WorkspaceForPath returns the workspace whose project path holds dir. When
+// projects are nested the innermost one wins.
+func WorkspaceForPath(dir string) (WorkspaceConfig, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return WorkspaceConfig{}, false
+	}
+	var match WorkspaceConfig
+	for _, ws := range Workspaces {
+		if ws.Project == "" || !withinDir(dir, ws.Project) {
+			continue
+		}
+		if len(ws.Project) > len(match.Project) {
+			match = ws
+		}
+	}
+	return match, match.Name != ""
+}
+
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
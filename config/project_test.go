@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	outer := filepath.Join(root, "repo", ProjectFileName)
+	writeFile(t, outer, "workspace: app\nenv: dev\n")
+	inner := filepath.Join(root, "repo", "services", "api", ProjectFileName)
+	writeFile(t, inner, "workspace: api\n")
+	// A .ryuk directory, like the one in the home directory, is not a
+	// project file.
+	if err := os.MkdirAll(filepath.Join(root, "home", ProjectFileName), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"repo/docs/guide", "repo/services/api/cmd", "home/code"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir  string
+		want ProjectConfig
+		ok   bool
+	}{
+		{"repo", ProjectConfig{Workspace: "app", Env: "dev", Path: outer}, true},
+		{"repo/docs/guide", ProjectConfig{Workspace: "app", Env: "dev", Path: outer}, true},
+		{"repo/services/api/cmd", ProjectConfig{Workspace: "api", Path: inner}, true},
+		{"home/code", ProjectConfig{}, false},
+		{".", ProjectConfig{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, ok, err := FindProjectFile(filepath.Join(root, tt.dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("FindProjectFile = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	t.Run("relative", func(t *testing.T) {
+		chdir(t, filepath.Join(root, "repo", "docs"))
+		got, ok, err := FindProjectFile(".")
+		if err != nil || !ok || got.Path != outer {
+			t.Errorf("FindProjectFile(.) = %+v, %v, %v; want %s", got, ok, err, outer)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		broken := filepath.Join(root, "broken", ProjectFileName)
+		writeFile(t, broken, "workspace: [")
+		if _, ok, err := FindProjectFile(filepath.Dir(broken)); ok || err == nil {
+			t.Errorf("FindProjectFile of an invalid file = %v, %v; want an error", ok, err)
+		}
+	})
+}
+
+func TestWorkspaceForPath(t *testing.T) {
+	setupConfig(t)
+	root := t.TempDir()
+	Workspaces = []WorkspaceConfig{
+		{Name: "none"},
+		{Name: "repo", Project: filepath.Join(root, "repo")},
+		{Name: "api", Project: filepath.Join(root, "repo", "services", "api")},
+		{Name: "repo2", Project: filepath.Join(root, "repo2")},
+	}
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"repo", "repo"},
+		{"repo/docs", "repo"},
+		{"repo/services", "repo"},
+		{"repo/services/api", "api"},
+		{"repo/services/api/cmd/server", "api"},
+		{"repo2/src", "repo2"},
+		{"repository", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			ws, ok := WorkspaceForPath(filepath.Join(root, tt.dir))
+			if ok != (tt.want != "") || ws.Name != tt.want {
+				t.Errorf("WorkspaceForPath = %q, %v; want %q", ws.Name, ok, tt.want)
+			}
+		})
+	}
+
+	t.Run("relative", func(t *testing.T) {
+		dir := filepath.Join(root, "repo", "docs")
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		chdir(t, dir)
+		if ws, ok := WorkspaceForPath("."); !ok || ws.Name != "repo" {
+			t.Errorf("WorkspaceForPath(.) = %q, %v; want repo", ws.Name, ok)
+		}
+	})
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
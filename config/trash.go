@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// DefaultTrashRetention is how long deleted workspaces are kept unless
+	// trash_retention is set in the config file.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+	trashMetaFile         = "workspace.json"
+	trashDBFile           = "db"
+)
+
+// TrashEntry is a deleted workspace waiting in the trash.
+type TrashEntry struct {
+	Workspace WorkspaceConfig `json:"workspace"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	Path      string          `json:"-"`
+}
+
+func TrashDir() string {
+	return filepath.Join(BasePath, "trash")
+}
+
+// TrashRetention returns the configured retention window for the trash.
+func TrashRetention() time.Duration {
+	value := viper.GetString("trash_retention")
+	if value == "" {
+		return DefaultTrashRetention
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil {
+		return DefaultTrashRetention
+	}
+	return retention
+}
+
+// TrashWorkspace moves the database of ws into the trash with move and
+// removes the workspace from the config.
+func TrashWorkspace(ws WorkspaceConfig, move MoveFunc) (TrashEntry, error) {
+	entry := TrashEntry{Workspace: ws, DeletedAt: time.Now().UTC()}
+	// Workspace names are free form, escape them so the entry always is a
+	// single directory inside the trash.
+	dir := fmt.Sprintf("%s-%s", url.PathEscape(ws.Name), entry.DeletedAt.Format("20060102T150405.000000000"))
+	entry.Path = filepath.Join(TrashDir(), dir)
+	if err := os.MkdirAll(entry.Path, 0700); err != nil {
+		return entry, err
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return entry, err
+	}
+	if err := os.WriteFile(filepath.Join(entry.Path, trashMetaFile), data, 0600); err != nil {
+		return entry, err
+	}
+	if err := move(ws, filepath.Join(entry.Path, trashDBFile)); err != nil {
+		os.RemoveAll(entry.Path)
+		return entry, fmt.Errorf("Error moving database to trash: %w", err)
+	}
+
+	DeleteWorkspace(ws.ID)
+	return entry, nil
+}
+
+// ListTrash returns the deleted workspaces, most recent first.
+func ListTrash() ([]TrashEntry, error) {
+	entries := []TrashEntry{}
+	dirs, err := os.ReadDir(TrashDir())
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		path := filepath.Join(TrashDir(), dir.Name())
+		data, err := os.ReadFile(filepath.Join(path, trashMetaFile))
+		if err != nil {
+			continue
+		}
+		entry := TrashEntry{}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entry.Path = path
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// RestoreWorkspace moves the most recently deleted workspace called name out
+// of the trash with move and adds it back to the config with its original ID.
+func RestoreWorkspace(name string, move MoveFunc) (WorkspaceConfig, error) {
+	entries, err := ListTrash()
+	if err != nil {
+		return WorkspaceConfig{}, err
+	}
+	for _, entry := range entries {
+		if entry.Workspace.Name != name {
+			continue
+		}
+		ws := entry.Workspace
+		if _, err := GetWorkspace(ws.Name); err == nil {
+			return ws, fmt.Errorf("Workspace '%s' already exists.", ws.Name)
+		}
+		if _, err := os.Stat(ws.DB); err == nil {
+			return ws, fmt.Errorf("A database already exists at %s", ws.DB)
+		}
+
+		trashed := ws
+		trashed.DB = filepath.Join(entry.Path, trashDBFile)
+		if err := move(trashed, ws.DB); err != nil {
+			return ws, fmt.Errorf("Error restoring database: %w", err)
+		}
+		if err := updateWorkspaces(ws); err != nil {
+			// Put the database back so the entry can be restored again.
+			if rerr := move(ws, trashed.DB); rerr != nil {
+				return ws, fmt.Errorf("%v; the database was left at %s: %v", err, ws.DB, rerr)
+			}
+			return ws, err
+		}
+		return ws, os.RemoveAll(entry.Path)
+	}
+	return WorkspaceConfig{}, fmt.Errorf("No deleted workspace called %s in the trash", name)
+}
+
+// PurgeTrash permanently removes trashed workspaces older than retention,
+// along with their environment snapshots.
+func PurgeTrash(retention time.Duration) ([]TrashEntry, error) {
+	entries, err := ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	purged := []TrashEntry{}
+	for _, entry := range entries {
+		if time.Since(entry.DeletedAt) < retention {
+			continue
+		}
+		if err := PurgeEntry(entry); err != nil {
+			return purged, err
+		}
+		purged = append(purged, entry)
+	}
+	return purged, nil
+}
+
+// PurgeEntry permanently removes a trashed workspace.
+func PurgeEntry(entry TrashEntry) error {
+	if err := os.RemoveAll(SnapshotDir(entry.Workspace)); err != nil {
+		return err
+	}
+	return os.RemoveAll(entry.Path)
+}
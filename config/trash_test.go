@@ -0,0 +1,203 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// setupConfig points the config at an empty ryuk home for the test.
+func setupConfig(t *testing.T) {
+	t.Helper()
+	basePath, workspaces := BasePath, Workspaces
+	BasePath = t.TempDir()
+	Workspaces = nil
+	viper.Reset()
+	viper.SetConfigFile(filepath.Join(BasePath, ".ryuk.yaml"))
+	t.Cleanup(func() {
+		BasePath, Workspaces = basePath, workspaces
+		viper.Reset()
+	})
+}
+
+// rename is a MoveFunc for workspaces that are not open anywhere.
+func rename(ws WorkspaceConfig, dst string) error {
+	if err := os.Rename(ws.DB, dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// addWorkspace saves a workspace called name with a database holding data.
+func addWorkspace(t *testing.T, name, data string) WorkspaceConfig {
+	t.Helper()
+	ws := WorkspaceConfig{ID: name + "-id", Name: name, DB: filepath.Join(BasePath, name)}
+	if err := os.WriteFile(ws.DB, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateWorkspaces(ws); err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func readDB(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTrashRestore(t *testing.T) {
+	setupConfig(t)
+	ws := addWorkspace(t, "app", "data")
+
+	entry, err := TrashWorkspace(ws, rename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetWorkspace("app"); err == nil {
+		t.Error("trashed workspace is still in the config")
+	}
+	if _, err := os.Stat(ws.DB); !os.IsNotExist(err) {
+		t.Error("trashed database is still in place")
+	}
+	if got := readDB(t, filepath.Join(entry.Path, trashDBFile)); got != "data" {
+		t.Errorf("trashed database = %q, want %q", got, "data")
+	}
+
+	entries, err := ListTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Workspace.ID != ws.ID || entries[0].Path != entry.Path {
+		t.Fatalf("ListTrash = %+v, want the entry of app", entries)
+	}
+
+	restored, err := RestoreWorkspace("app", rename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != ws.ID {
+		t.Errorf("restored ID = %s, want %s", restored.ID, ws.ID)
+	}
+	if got, err := GetWorkspace("app"); err != nil || got.ID != ws.ID {
+		t.Errorf("GetWorkspace(app) = %+v, %v", got, err)
+	}
+	if got := readDB(t, ws.DB); got != "data" {
+		t.Errorf("restored database = %q, want %q", got, "data")
+	}
+	if _, err := os.Stat(entry.Path); !os.IsNotExist(err) {
+		t.Error("the trash entry was kept after restoring")
+	}
+	if _, err := RestoreWorkspace("app", rename); err == nil {
+		t.Error("restoring a workspace that is not in the trash did not fail")
+	}
+}
+
+func TestTrashMoveFails(t *testing.T) {
+	setupConfig(t)
+	ws := addWorkspace(t, "app", "data")
+	locked := func(WorkspaceConfig, string) error { return os.ErrPermission }
+
+	if _, err := TrashWorkspace(ws, locked); err == nil {
+		t.Fatal("TrashWorkspace did not fail")
+	}
+	if _, err := GetWorkspace("app"); err != nil {
+		t.Errorf("workspace was removed from the config: %v", err)
+	}
+	if entries, _ := ListTrash(); len(entries) != 0 {
+		t.Errorf("ListTrash = %+v, want no entries", entries)
+	}
+}
+
+func TestRestoreRollback(t *testing.T) {
+	setupConfig(t)
+	ws := addWorkspace(t, "app", "data")
+	entry, err := TrashWorkspace(ws, rename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another workspace took the database path in the meantime.
+	if err := os.WriteFile(ws.DB, []byte("other"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreWorkspace("app", rename); err == nil {
+		t.Fatal("RestoreWorkspace over an existing database did not fail")
+	}
+	os.Remove(ws.DB)
+
+	// Another process adds a workspace with the same name while the
+	// database is restored, so the database goes back to the trash.
+	viper.Set("workspaces", append(Workspaces, WorkspaceConfig{ID: "other", Name: "app"}))
+	moves := 0
+	counted := func(ws WorkspaceConfig, dst string) error {
+		moves++
+		return rename(ws, dst)
+	}
+	_, err = RestoreWorkspace("app", counted)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("RestoreWorkspace returned %v, want an already exists error", err)
+	}
+	if moves != 2 {
+		t.Errorf("the database was moved %d times, want there and back", moves)
+	}
+	if _, err := os.Stat(ws.DB); !os.IsNotExist(err) {
+		t.Error("the database was left in place after a failed restore")
+	}
+	if got := readDB(t, filepath.Join(entry.Path, trashDBFile)); got != "data" {
+		t.Errorf("trashed database = %q, want %q", got, "data")
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	setupConfig(t)
+	old, err := TrashWorkspace(addWorkspace(t, "old", "1"), rename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.DeletedAt = old.DeletedAt.Add(-48 * time.Hour)
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(old.Path, trashMetaFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	snapshots := SnapshotDir(old.Workspace)
+	if err := os.MkdirAll(snapshots, 0700); err != nil {
+		t.Fatal(err)
+	}
+	recent, err := TrashWorkspace(addWorkspace(t, "recent", "2"), rename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := PurgeTrash(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 1 || purged[0].Workspace.Name != "old" {
+		t.Fatalf("PurgeTrash purged %+v, want old", purged)
+	}
+	for _, gone := range []string{old.Path, snapshots} {
+		if _, err := os.Stat(gone); !os.IsNotExist(err) {
+			t.Errorf("%s was not purged", gone)
+		}
+	}
+	entries, err := ListTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != recent.Path {
+		t.Errorf("ListTrash = %+v, want only recent", entries)
+	}
+}
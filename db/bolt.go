@@ -0,0 +1,160 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltStore keeps a workspace in a single bbolt file. bbolt locks the file
+// for as long as it is open: exclusively for writers, shared for readers.
+// Writers also leave their PID in a lock file next to it so that processes
+// kept waiting can say who holds the workspace.
+type boltStore struct {
+	db       *bolt.DB
+	lockFile string
+}
+
+func openBolt(path string, opts Options) (*boltStore, error) {
+	// bbolt waits forever on a zero timeout, while ryuk fails straight away.
+	timeout := opts.LockTimeout
+	if timeout <= 0 {
+		timeout = time.Nanosecond
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: timeout, ReadOnly: opts.ReadOnly})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, &LockedError{Path: path, PID: lockHolder(path + lockSuffix)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error opening DB: %v", err)
+	}
+
+	s := &boltStore{db: db}
+	if !opts.ReadOnly {
+		s.lockFile = path + lockSuffix
+		if err := os.WriteFile(s.lockFile, []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("Error writing lock file: %v", err)
+		}
+	}
+	return s, nil
+}
+
+const lockSuffix = ".lock"
+
+// lockHolder returns the PID recorded in lockFile, or 0 when there is none.
+// The file is only a hint: the lock itself is held on the database.
+func lockHolder(lockFile string) int {
+	data, err := os.ReadFile(lockFile)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	// Ignore files left behind by processes that were killed.
+	process, err := os.FindProcess(pid)
+	if err != nil || process.Signal(syscall.Signal(0)) != nil {
+		return 0
+	}
+	return pid
+}
+
+func (s *boltStore) Update(fn func(Tx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (s *boltStore) View(fn func(Tx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (s *boltStore) Close() error {
+	if s.lockFile != "" {
+		os.Remove(s.lockFile)
+	}
+	return s.db.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t boltTx) Bucket(name string) Bucket {
+	b := t.tx.Bucket([]byte(name))
+	if b == nil {
+		return nil
+	}
+	return boltBucket{b}
+}
+
+func (t boltTx) CreateBucket(name string) (Bucket, error) {
+	b, err := t.tx.CreateBucket([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{b}, nil
+}
+
+func (t boltTx) CreateBucketIfNotExists(name string) (Bucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{b}, nil
+}
+
+func (t boltTx) DeleteBucket(name string) error {
+	return t.tx.DeleteBucket([]byte(name))
+}
+
+func (t boltTx) ForEach(fn func(name string, b Bucket) error) error {
+	return t.tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		return fn(string(name), boltBucket{b})
+	})
+}
+
+type boltBucket struct {
+	b *bolt.Bucket
+}
+
+func (b boltBucket) Get(key []byte) []byte {
+	return b.b.Get(key)
+}
+
+func (b boltBucket) Put(key, value []byte) error {
+	return b.b.Put(key, value)
+}
+
+func (b boltBucket) Delete(key []byte) error {
+	return b.b.Delete(key)
+}
+
+func (b boltBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.b.ForEach(fn)
+}
+
+func (b boltBucket) ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error {
+	cursor := b.b.Cursor()
+	for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b boltBucket) Len() int {
+	return b.b.Stats().KeyN
+}
@@ -1,16 +1,25 @@
 package db
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"log"
-	"time"
+	"strings"
 
-	bolt "go.etcd.io/bbolt"
+	"github.com/Brian-Kariu/ryuk/config"
 )
 
+// Config is a variable to write. Metadata left nil keeps what is stored
+// for the key already.
 type Config struct {
-	Key   []byte
-	Value []byte
+	Key         []byte
+	Value       []byte
+	Description *string
+	Tags        []string
+	Owner       *string
+	// Secret classifies the key explicitly when set. Otherwise the key is
+	// classified by the schema or its name.
+	Secret *bool
 }
 
 func (c Config) ToBytes() (key []byte, value []byte) {
@@ -21,126 +30,455 @@ type configs struct {
 	config Config
 }
 
+// GlobalBucket holds the variables shared by every environment of a
+// workspace. Environments override them.
+const GlobalBucket = "global_configs"
+
+// internalPrefix starts the names of the buckets ryuk keeps for itself,
+// such as history and the meta bucket.
+const internalPrefix = "__"
+
+// ValidateEnvName returns an error when name can not be used for an
+// environment because it would clash with the global bucket or the internal
+// buckets of a workspace.
+func ValidateEnvName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("Environment name not set")
+	case name == GlobalBucket:
+		return fmt.Errorf("%s is reserved for global variables", name)
+	case strings.HasPrefix(name, internalPrefix):
+		return fmt.Errorf("Environment names starting with %s are reserved for ryuk", internalPrefix)
+	}
+	return nil
+}
+
 type client struct {
 	name         string
 	globalBucket string
-	db           *bolt.DB
-}
-
-func openDB(name string) (*bolt.DB, error) {
-	db, err := bolt.Open(name, 0600, &bolt.Options{Timeout: 1 * time.Second})
-	if err != nil {
-		return nil, fmt.Errorf("Error opening DB: %v", err)
-	}
-	return db, nil
+	db           Store
+	sealer       *sealer
+	// encrypted is set once every stored value is known to be sealed.
+	encrypted bool
 }
 
 func (c client) String() string {
 	return fmt.Sprintf("{name:%s, globalBucket:%s}", c.name, c.globalBucket)
 }
 
-func (c client) CreateBucket(name string) {
-	dbError := c.db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(name))
-		if err != nil {
+// CreateBucket creates the bucket of an environment if it does not exist.
+func (c client) CreateBucket(name string) error {
+	return c.db.Update(func(tx Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
 			return fmt.Errorf("Error creating bucket: %s", err)
 		}
-		log.Printf("New bucket %s created in db %s\n", name, c.name)
 		return nil
 	})
-	if dbError != nil {
-		fmt.Printf("createBucket: %s", dbError)
-	}
-	defer c.db.Close()
 }
 
+// AddKey sets a variable in bucket. The global bucket is created on first
+// use.
 func (c client) AddKey(bucket string, data Config) error {
-	err := c.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucket))
+	err := c.db.Update(func(tx Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil && bucket == c.globalBucket {
+			created, err := tx.CreateBucketIfNotExists(bucket)
+			if err != nil {
+				return fmt.Errorf("Error creating bucket: %s", err)
+			}
+			b = created
+		}
 		if b == nil {
-			return fmt.Errorf("bucket %s does not exist", bucket)
+			return envNotFound(bucket)
+		}
+
+		previous, err := c.readRecord(tx, b.Get(data.Key), bucket, string(data.Key))
+		if err != nil {
+			return err
+		}
+		value, err := c.sealRecord(data.record(previous), bucket, string(data.Key))
+		if err != nil {
+			return err
 		}
+		if err := b.Put([]byte(data.Key), value); err != nil {
+			return err
+		}
+		return c.recordHistory(tx, bucket, string(data.Key), previous.value(), string(data.Value), OpSet)
+	})
+	return err
+}
 
-		return b.Put([]byte(data.Key), []byte(data.Value))
+// GetKey returns the value of config in bucket. When parents are given the
+// key is looked up through them in order if bucket does not define it, and
+// then in the global bucket.
+func (c client) GetKey(bucket string, config string, parents ...string) (string, error) {
+	v := ""
+	err := c.db.View(func(tx Tx) error {
+		for _, name := range c.withGlobals(append([]string{bucket}, parents...)) {
+			b := tx.Bucket(name)
+			if b == nil && name == c.globalBucket {
+				continue
+			}
+			if b == nil {
+				return envNotFound(name)
+			}
+			r, err := c.readRecord(tx, b.Get([]byte(config)), name, config)
+			if err != nil {
+				return err
+			}
+			if r == nil {
+				continue
+			}
+			v = r.Value
+			return nil
+		}
+		return keyNotFound(config, bucket)
 	})
 	if err != nil {
-		log.Printf("Error adding key to bucket %s: %v\n", bucket, err)
-		return err
+		return "", err
 	}
+	return v, nil
+}
 
-	log.Printf("Added config: %s, to bucket: %s\n", data.Key, bucket)
-	return nil
+type ConflictMode int
+
+const (
+	// ConflictFail aborts an import when a key already holds another value.
+	ConflictFail ConflictMode = iota
+	// ConflictOverwrite replaces existing values.
+	ConflictOverwrite
+	// ConflictSkip keeps existing values.
+	ConflictSkip
+)
+
+type ImportSummary struct {
+	Added     []string
+	Changed   []string
+	Unchanged []string
+	Skipped   []string
+}
+
+// ImportKeys writes all configs to bucket in a single transaction. Nothing is
+// written if mode is ConflictFail and any key already holds a different value.
+func (c client) ImportKeys(bucket string, data []Config, mode ConflictMode) (ImportSummary, error) {
+	return c.putKeys(bucket, data, mode, OpImport)
 }
 
-func (c client) GetKey(bucket string, config string) {
-	v := []byte("")
-	err := c.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucket))
+// PromoteKeys overwrites the given keys of bucket in a single transaction.
+func (c client) PromoteKeys(bucket string, data []Config) (ImportSummary, error) {
+	return c.putKeys(bucket, data, ConflictOverwrite, OpPromote)
+}
+
+func (c client) putKeys(bucket string, data []Config, mode ConflictMode, operation string) (ImportSummary, error) {
+	summary := ImportSummary{}
+	err := c.db.Update(func(tx Tx) error {
+		b := tx.Bucket(bucket)
 		if b == nil {
-			return fmt.Errorf("bucket %s not found", bucket)
+			return envNotFound(bucket)
+		}
+
+		conflicts := []string{}
+		for _, entry := range data {
+			key := string(entry.Key)
+			current, err := c.readRecord(tx, b.Get(entry.Key), bucket, key)
+			if err != nil {
+				return fmt.Errorf("key %s: %v", key, err)
+			}
+			if current != nil {
+				if current.Value == string(entry.Value) {
+					summary.Unchanged = append(summary.Unchanged, key)
+					continue
+				}
+				switch mode {
+				case ConflictSkip:
+					summary.Skipped = append(summary.Skipped, key)
+					continue
+				case ConflictFail:
+					conflicts = append(conflicts, key)
+					continue
+				}
+			}
+
+			value, err := c.sealRecord(entry.record(current), bucket, key)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(entry.Key, value); err != nil {
+				return err
+			}
+			if err := c.recordHistory(tx, bucket, key, current.value(), string(entry.Value), operation); err != nil {
+				return err
+			}
+			if current != nil {
+				summary.Changed = append(summary.Changed, key)
+			} else {
+				summary.Added = append(summary.Added, key)
+			}
 		}
-		v = b.Get([]byte(config))
 
-		// HACK: This should change when a config can be json
-		fmt.Printf("%s", string(v))
+		if len(conflicts) > 0 {
+			return fmt.Errorf("keys already exist with different values: %s", strings.Join(conflicts, ", "))
+		}
 		return nil
 	})
 	if err != nil {
-		log.Printf("Error retrieving key %s\n", config)
+		return ImportSummary{}, err
 	}
+	return summary, nil
 }
 
-func (c client) ListVars(bucket string) (map[string]string, error) {
-	envVars := make(map[string]string)
-	err := c.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucket))
-		if b == nil {
-			return fmt.Errorf("bucket %s not found", bucket)
+// ResolvedVar is the effective value of a variable and the bucket it was
+// read from.
+type ResolvedVar struct {
+	Value string
+	Layer string
+	// Record is what is stored for the variable in Layer. Its value is
+	// never expanded.
+	Record Record
+	// Secret is filled in by the resolve package, which knows the schema.
+	Secret bool
+}
+
+// ResolveVars merges the variables of chain, where earlier buckets override
+// later ones. Global variables are merged underneath the whole chain.
+func (c client) ResolveVars(chain []string) (map[string]ResolvedVar, error) {
+	envVars := make(map[string]ResolvedVar)
+	err := c.db.View(func(tx Tx) error {
+		for _, bucket := range c.withGlobals(chain) {
+			b := tx.Bucket(bucket)
+			if b == nil && bucket == c.globalBucket {
+				continue
+			}
+			if b == nil {
+				return envNotFound(bucket)
+			}
+
+			err := b.ForEach(func(k, v []byte) error {
+				if _, ok := envVars[string(k)]; ok {
+					return nil
+				}
+				r, err := c.openRecord(tx, v, bucket, string(k))
+				if err != nil {
+					return fmt.Errorf("key %s: %v", k, err)
+				}
+				envVars[string(k)] = ResolvedVar{Value: r.Value, Layer: bucket, Record: r}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return envVars, err
+}
+
+// withGlobals appends the global bucket to chain unless it is already part
+// of it.
+func (c client) withGlobals(chain []string) []string {
+	for _, bucket := range chain {
+		if bucket == c.globalBucket {
+			return chain
 		}
+	}
+	return append(append([]string{}, chain...), c.globalBucket)
+}
 
-		return b.ForEach(func(k, v []byte) error {
-			envVars[string(k)] = string(v)
+// ListVars returns the variables of bucket. When parents are given, their
+// variables are included unless bucket overrides them.
+func (c client) ListVars(bucket string, parents ...string) (map[string]string, error) {
+	resolved, err := c.ResolveVars(append([]string{bucket}, parents...))
+	envVars := make(map[string]string, len(resolved))
+	for k, v := range resolved {
+		envVars[k] = v.Value
+	}
+	return envVars, err
+}
+
+// EncryptAll seals every plaintext value left in the database by versions
+// of ryuk that stored values unencrypted, and binds values sealed before
+// v2 to their key. The database is then marked as encrypted so that no
+// stored value is taken for plaintext again. It returns how many values
+// were sealed.
+func (c client) EncryptAll() (int, error) {
+	sealed := 0
+	err := c.db.Update(func(tx Tx) error {
+		opened, failed := 0, 0
+		err := tx.ForEach(func(name string, b Bucket) error {
+			if name == metaBucket {
+				return nil
+			}
+			pending := map[string][]byte{}
+			err := b.ForEach(func(k, v []byte) error {
+				value := append([]byte{}, v...)
+				if isSealed(v) {
+					// A plaintext value may happen to start with either
+					// prefix, so only values that decrypt count as sealed.
+					plaintext, err := c.sealer.open(v, valueAAD(name, string(k)))
+					switch {
+					case err == nil && bytes.HasPrefix(v, sealedPrefix):
+						opened++
+						return nil
+					case err == nil:
+						opened++
+						value = plaintext
+					case c.encrypted:
+						// Marked databases hold nothing but sealed values.
+						failed++
+						return nil
+					default:
+						failed++
+					}
+				}
+				pending[string(k)] = value
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for k, v := range pending {
+				value, err := c.sealer.seal(v, valueAAD(name, k))
+				if err != nil {
+					return err
+				}
+				if err := b.Put([]byte(k), value); err != nil {
+					return fmt.Errorf("bucket %s: %v", name, err)
+				}
+				sealed++
+			}
 			return nil
 		})
+		if err != nil {
+			return err
+		}
+		// None of the sealed values opening means the key is wrong, not
+		// that they are all plaintext.
+		if failed > 0 && opened == 0 {
+			return fmt.Errorf("unable to decrypt %d values, check the encryption key", failed)
+		}
+		return markEncrypted(tx)
 	})
-	defer c.db.Close()
-	return envVars, err
+	if err != nil {
+		return 0, err
+	}
+	return sealed, nil
 }
 
-func (c client) DeleteKey(bucket, config string) {
-	err := c.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucket))
+// DeleteKey removes config from bucket. It fails if the key does not exist.
+func (c client) DeleteKey(bucket, config string) error {
+	err := c.db.Update(func(tx Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil && bucket == c.globalBucket {
+			return keyNotFound(config, bucket)
+		}
 		if b == nil {
-			return fmt.Errorf("bucket %s not found", bucket)
+			return envNotFound(bucket)
 		}
 
-		err := b.Delete([]byte(config))
-		return err
+		previous, err := c.readRecord(tx, b.Get([]byte(config)), bucket, config)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			return keyNotFound(config, bucket)
+		}
+		if err := b.Delete([]byte(config)); err != nil {
+			return err
+		}
+		if err := deleteLegacySecretFlag(tx, bucket, config); err != nil {
+			return err
+		}
+		return c.recordHistory(tx, bucket, config, previous.value(), "", OpDelete)
 	})
-	if err != nil {
-		log.Fatal("Delete operation failed: %s", err)
-	}
+	return err
+}
+
+// NewClient opens the store of ws for reading and writing. Only one process
+// can hold a workspace open for writing, so call Close as soon as possible.
+func NewClient(ws config.WorkspaceConfig, globalBucket string) (*client, error) {
+	return newClient(ws, globalBucket, false)
+}
+
+// NewReadOnlyClient opens the store of ws for reading. Any number of
+// processes can read a workspace at the same time.
+func NewReadOnlyClient(ws config.WorkspaceConfig, globalBucket string) (*client, error) {
+	return newClient(ws, globalBucket, true)
+}
 
-	log.Printf("Config %s has been deleted", config)
+// NewClientFrom opens the store of ws with the encryption key and lock
+// timeout of f instead of those of the global config.
+func NewClientFrom(f *config.File, ws config.WorkspaceConfig, readOnly bool) (*client, error) {
+	return openClient(f, ws, GlobalBucket, readOnly)
 }
 
-func NewClient(name, globalBucket string) (*client, error) {
+func newClient(ws config.WorkspaceConfig, globalBucket string, readOnly bool) (*client, error) {
+	return openClient(config.GlobalFile(), ws, globalBucket, readOnly)
+}
+
+func openClient(f *config.File, ws config.WorkspaceConfig, globalBucket string, readOnly bool) (*client, error) {
 	if globalBucket == "" {
-		globalBucket = "global_configs"
+		globalBucket = GlobalBucket
 	}
 
+	name := ws.DB
 	if name == "" {
 		name = "ryuk"
 	}
-	db, err := openDB(name)
+	key, err := f.EncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	sealer, err := newSealer(key)
 	if err != nil {
 		return nil, err
 	}
 
+	db, err := OpenStore(ws.Backend, name, Options{ReadOnly: readOnly, LockTimeout: f.LockTimeout()})
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		locked.Workspace = ws.Name
+	}
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := checkEncrypted(db, readOnly)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	clientInstance := &client{
 		name:         name,
 		globalBucket: globalBucket,
 		db:           db,
+		sealer:       sealer,
+		encrypted:    encrypted,
 	}
 	return clientInstance, nil
 }
+
+// Close releases the store. The client can not be used afterwards.
+func (c client) Close() error {
+	return c.db.Close()
+}
+
+// MoveWorkspace moves the database of ws to dst once no other process holds
+// it, see MoveStore.
+func MoveWorkspace(ws config.WorkspaceConfig, dst string) error {
+	err := MoveStore(ws.Backend, ws.DB, dst, Options{LockTimeout: config.LockTimeout()})
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		locked.Workspace = ws.Name
+	}
+	return err
+}
+
+// OpenWorkspace opens the store of the workspace called name.
+func OpenWorkspace(name, globalBucket string) (*client, error) {
+	ws, err := config.GetWorkspace(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(ws, globalBucket)
+}
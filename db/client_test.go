@@ -0,0 +1,211 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Brian-Kariu/ryuk/config"
+)
+
+// newTestClient returns a client over an in-memory workspace holding the
+// given environments.
+func newTestClient(t *testing.T, envs ...string) *client {
+	t.Helper()
+	ws := config.WorkspaceConfig{Name: "app", DB: t.Name(), Backend: BackendMemory}
+	c, err := NewClientFrom(config.NewFile(nil, make([]byte, 32)), ws, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	for _, env := range envs {
+		if err := c.CreateBucket(env); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// set stores vars in bucket, failing the test on error.
+func set(t *testing.T, c *client, bucket string, vars map[string]string) {
+	t.Helper()
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := c.AddKey(bucket, Config{Key: []byte(k), Value: []byte(vars[k])}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// operations returns the operation of every revision of key.
+func operations(t *testing.T, c *client, bucket, key string) []string {
+	t.Helper()
+	revisions, err := c.History(bucket, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := []string{}
+	for _, r := range revisions {
+		ops = append(ops, r.Operation)
+	}
+	return ops
+}
+
+func TestValidateEnvName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"dev", true},
+		{"prod_eu", true},
+		{"_private", true},
+		{"", false},
+		{GlobalBucket, false},
+		{metaBucket, false},
+		{historyBucket("dev"), false},
+		{legacySecretBucket("dev"), false},
+		{"__anything", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateEnvName(tt.name); (err == nil) != tt.ok {
+				t.Errorf("ValidateEnvName(%q) = %v, want ok %v", tt.name, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPromoteKeys(t *testing.T) {
+	c := newTestClient(t, "prod")
+	set(t, c, "prod", map[string]string{"HOST": "old", "PORT": "80"})
+
+	secret := true
+	summary, err := c.PromoteKeys("prod", []Config{
+		{Key: []byte("HOST"), Value: []byte("new")},
+		{Key: []byte("PORT"), Value: []byte("80")},
+		{Key: []byte("TOKEN"), Value: []byte("t"), Secret: &secret},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ImportSummary{Added: []string{"TOKEN"}, Changed: []string{"HOST"}, Unchanged: []string{"PORT"}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("PromoteKeys = %+v, want %+v", summary, want)
+	}
+
+	vars, err := c.ResolveVars([]string{"prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"HOST": "new", "PORT": "80", "TOKEN": "t"} {
+		if got := vars[key].Value; got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if s := vars["TOKEN"].Record.Secret; s == nil || !*s {
+		t.Error("promoted secret lost its classification")
+	}
+	if got, want := operations(t, c, "prod", "HOST"), []string{OpSet, OpPromote}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HOST history = %v, want %v", got, want)
+	}
+	if got, want := operations(t, c, "prod", "PORT"), []string{OpSet}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unchanged PORT history = %v, want %v", got, want)
+	}
+
+	if _, err := c.PromoteKeys("stg", []Config{{Key: []byte("A"), Value: []byte("1")}}); !errors.Is(err, ErrEnvNotFound) {
+		t.Errorf("PromoteKeys into a missing environment returned %v", err)
+	}
+}
+
+func TestResolveVars(t *testing.T) {
+	c := newTestClient(t, "base", "staging", "prod")
+	set(t, c, GlobalBucket, map[string]string{"REGION": "eu", "HOST": "global"})
+	set(t, c, "base", map[string]string{"HOST": "base", "PORT": "5432", "DEBUG": "true"})
+	set(t, c, "staging", map[string]string{"HOST": "staging", "DEBUG": "false"})
+	set(t, c, "prod", map[string]string{"HOST": "prod"})
+
+	vars, err := c.ResolveVars([]string{"prod", "staging", "base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ResolvedVar{
+		"HOST":   {Value: "prod", Layer: "prod"},
+		"DEBUG":  {Value: "false", Layer: "staging"},
+		"PORT":   {Value: "5432", Layer: "base"},
+		"REGION": {Value: "eu", Layer: GlobalBucket},
+	}
+	if len(vars) != len(want) {
+		t.Errorf("ResolveVars returned %d variables, want %d", len(vars), len(want))
+	}
+	for key, w := range want {
+		if got := vars[key]; got.Value != w.Value || got.Layer != w.Layer {
+			t.Errorf("%s = %q from %s, want %q from %s", key, got.Value, got.Layer, w.Value, w.Layer)
+		}
+	}
+
+	if _, err := c.ResolveVars([]string{"prod", "qa"}); !errors.Is(err, ErrEnvNotFound) {
+		t.Errorf("ResolveVars with a missing parent returned %v, want %v", err, ErrEnvNotFound)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	c := newTestClient(t, "dev", "prod")
+	set(t, c, GlobalBucket, map[string]string{"REGION": "eu", "HOST": "global"})
+	set(t, c, "dev", map[string]string{"HOST": "localhost"})
+
+	tests := []struct {
+		env  string
+		want map[string]string
+	}{
+		{"dev", map[string]string{"REGION": "eu", "HOST": "localhost"}},
+		{"prod", map[string]string{"REGION": "eu", "HOST": "global"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			vars, err := c.ListVars(tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(vars, tt.want) {
+				t.Errorf("ListVars = %v, want %v", vars, tt.want)
+			}
+			for key, want := range tt.want {
+				if got, err := c.GetKey(tt.env, key); err != nil || got != want {
+					t.Errorf("GetKey(%s) = %q, %v; want %q", key, got, err, want)
+				}
+			}
+		})
+	}
+
+	// Global writes stay out of the environments.
+	err := c.db.View(func(tx Tx) error {
+		if v := tx.Bucket("prod").Get([]byte("REGION")); v != nil {
+			t.Error("REGION was written to prod")
+		}
+		if tx.Bucket(GlobalBucket).Get([]byte("REGION")) == nil {
+			t.Error("REGION is missing from the global bucket")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := operations(t, c, GlobalBucket, "REGION"), []string{OpSet}; !reflect.DeepEqual(got, want) {
+		t.Errorf("global history = %v, want %v", got, want)
+	}
+
+	if err := c.DeleteKey(GlobalBucket, "HOST"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetKey("prod", "HOST"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("GetKey after deleting the global returned %v, want %v", err, ErrKeyNotFound)
+	}
+	if got, err := c.GetKey("dev", "HOST"); err != nil || got != "localhost" {
+		t.Errorf("dev HOST = %q, %v; want localhost", got, err)
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+var (
+	// sealedPrefix marks values encrypted by ryuk and bound to the bucket and
+	// key they are stored under.
+	sealedPrefix = []byte("ryuk:v2:")
+	// legacyPrefix marks values encrypted by versions of ryuk that did not
+	// bind them to their key. They are upgraded by ryuk workspace encrypt.
+	legacyPrefix = []byte("ryuk:v1:")
+)
+
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(key []byte) (*sealer, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating cipher: %v", err)
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// valueAAD is the additional data a value is sealed with, so it can not be
+// moved to another key or bucket and still decrypt.
+func valueAAD(bucket, key string) []byte {
+	return []byte(bucket + "/" + key)
+}
+
+// isSealed reports whether value looks encrypted. In a database that is
+// not fully encrypted yet a plaintext value can look the same, see
+// client.openValue.
+func isSealed(value []byte) bool {
+	return bytes.HasPrefix(value, sealedPrefix) || bytes.HasPrefix(value, legacyPrefix)
+}
+
+func (s *sealer) seal(plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("Error generating nonce: %v", err)
+	}
+
+	sealed := make([]byte, 0, len(sealedPrefix)+len(nonce)+len(plaintext)+s.aead.Overhead())
+	sealed = append(sealed, sealedPrefix...)
+	sealed = append(sealed, nonce...)
+	return s.aead.Seal(sealed, nonce, plaintext, aad), nil
+}
+
+// open decrypts a sealed value. Legacy values were sealed without aad.
+func (s *sealer) open(value, aad []byte) ([]byte, error) {
+	var data []byte
+	switch {
+	case bytes.HasPrefix(value, sealedPrefix):
+		data = value[len(sealedPrefix):]
+	case bytes.HasPrefix(value, legacyPrefix):
+		data = value[len(legacyPrefix):]
+		aad = nil
+	default:
+		return nil, fmt.Errorf("value is not encrypted")
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("sealed value is truncated")
+	}
+	plaintext, err := s.aead.Open(nil, data[:nonceSize], data[nonceSize:], aad)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt value, is the passphrase or key file correct? %v", err)
+	}
+	return plaintext, nil
+}
+
+// metaBucket holds ryuk's own bookkeeping for a database.
+const metaBucket = "__ryuk__"
+
+// encryptedKey is set in metaBucket once every value is sealed.
+var encryptedKey = []byte("encrypted")
+
+// checkEncrypted reports whether store is marked as holding only sealed
+// values. A new store opened for writing is marked straight away, since
+// everything written to it is sealed.
+func checkEncrypted(store Store, readOnly bool) (bool, error) {
+	encrypted, empty := false, true
+	err := store.View(func(tx Tx) error {
+		return tx.ForEach(func(name string, b Bucket) error {
+			if name == metaBucket {
+				encrypted = b.Get(encryptedKey) != nil
+			} else {
+				empty = false
+			}
+			return nil
+		})
+	})
+	if err != nil || encrypted || !empty || readOnly {
+		return encrypted, err
+	}
+	return true, store.Update(markEncrypted)
+}
+
+func markEncrypted(tx Tx) error {
+	b, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(encryptedKey, []byte("v2"))
+}
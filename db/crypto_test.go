@@ -0,0 +1,210 @@
+package db
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func newTestSealer(t *testing.T) *sealer {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	s, err := newSealer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// sealLegacy seals plaintext the way ryuk:v1 values were, without aad.
+func sealLegacy(t *testing.T, s *sealer, plaintext []byte) []byte {
+	t.Helper()
+	sealed, err := s.seal(plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(append([]byte{}, legacyPrefix...), sealed[len(sealedPrefix):]...)
+}
+
+func TestSealOpen(t *testing.T) {
+	s := newTestSealer(t)
+	tests := []struct {
+		name      string
+		plaintext []byte
+		aad       []byte
+	}{
+		{"value", []byte("postgres://localhost"), valueAAD("dev", "DATABASE_URL")},
+		{"empty value", []byte{}, valueAAD("dev", "EMPTY")},
+		{"history", []byte("old"), valueAAD(historyBucket("dev"), "KEY")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := s.seal(tt.plaintext, tt.aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !isSealed(sealed) || !bytes.HasPrefix(sealed, sealedPrefix) {
+				t.Fatalf("sealed value %q does not carry %q", sealed, sealedPrefix)
+			}
+			got, err := s.open(sealed, tt.aad)
+			if err != nil {
+				t.Fatalf("open returned %v", err)
+			}
+			if !bytes.Equal(got, tt.plaintext) {
+				t.Errorf("open = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	s := newTestSealer(t)
+	aad := valueAAD("dev", "KEY")
+	sealed, err := s.seal([]byte("value"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		value []byte
+		aad   []byte
+	}{
+		{"other key", sealed, valueAAD("dev", "OTHER")},
+		{"other bucket", sealed, valueAAD("prod", "KEY")},
+		{"other sealer", func() []byte {
+			v, _ := newTestSealer(t).seal([]byte("value"), aad)
+			return v
+		}(), aad},
+		{"truncated", sealed[:len(sealedPrefix)+4], aad},
+		{"plaintext", []byte("value"), aad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := s.open(tt.value, tt.aad); err == nil {
+				t.Errorf("open = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestOpenLegacy(t *testing.T) {
+	s := newTestSealer(t)
+	legacy := sealLegacy(t, s, []byte("value"))
+	// Legacy values were not bound to their key, so any aad opens them.
+	for _, aad := range [][]byte{nil, valueAAD("dev", "KEY")} {
+		got, err := s.open(legacy, aad)
+		if err != nil {
+			t.Fatalf("open(%q) returned %v", aad, err)
+		}
+		if string(got) != "value" {
+			t.Errorf("open(%q) = %q, want %q", aad, got, "value")
+		}
+	}
+}
+
+func TestEncryptAll(t *testing.T) {
+	c := newTestClient(t, "dev")
+	sealedV2, err := c.sealValue([]byte("sealed"), "dev", "SEALED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := map[string][]byte{
+		"PLAIN":     []byte("hello"),
+		"SEALED":    sealedV2,
+		"LEGACY":    sealLegacy(t, c.sealer, []byte("legacy")),
+		"FAKE_V2":   []byte("ryuk:v2:not sealed at all"),
+		"FAKE_V1":   []byte("ryuk:v1:nor this"),
+		"EMPTY_V2":  append([]byte{}, sealedPrefix...),
+		"EMPTY_VAL": {},
+	}
+	err = c.db.Update(func(tx Tx) error {
+		b := tx.Bucket("dev")
+		for k, v := range stored {
+			if err := b.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Written by a version that stored plaintext.
+	c.encrypted = false
+
+	count, err := c.EncryptAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(stored)-1 {
+		t.Errorf("EncryptAll sealed %d values, want %d", count, len(stored)-1)
+	}
+	want := map[string]string{
+		"PLAIN":     "hello",
+		"SEALED":    "sealed",
+		"LEGACY":    "legacy",
+		"FAKE_V2":   "ryuk:v2:not sealed at all",
+		"FAKE_V1":   "ryuk:v1:nor this",
+		"EMPTY_V2":  string(sealedPrefix),
+		"EMPTY_VAL": "",
+	}
+	err = c.db.View(func(tx Tx) error {
+		b := tx.Bucket("dev")
+		for k, w := range want {
+			v := b.Get([]byte(k))
+			got, err := c.sealer.open(v, valueAAD("dev", k))
+			if err != nil {
+				t.Errorf("%s does not open: %v", k, err)
+				continue
+			}
+			if string(got) != w {
+				t.Errorf("%s = %q, want %q", k, got, w)
+			}
+		}
+		if v := b.Get([]byte("SEALED")); !bytes.Equal(v, sealedV2) {
+			t.Error("a value that was already sealed was sealed again")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted, err := checkEncrypted(c.db, true); err != nil || !encrypted {
+		t.Errorf("checkEncrypted = %v, %v; want the database marked", encrypted, err)
+	}
+
+	// Once marked, running it again changes nothing.
+	c.encrypted = true
+	if count, err := c.EncryptAll(); err != nil || count != 0 {
+		t.Errorf("second EncryptAll = %d, %v; want nothing sealed", count, err)
+	}
+}
+
+func TestEncryptAllWrongKey(t *testing.T) {
+	c := newTestClient(t, "dev")
+	other := newTestSealer(t)
+	sealed, err := other.seal([]byte("value"), valueAAD("dev", "KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.db.Update(func(tx Tx) error {
+		return tx.Bucket("dev").Put([]byte("KEY"), sealed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.encrypted = false
+
+	if _, err := c.EncryptAll(); err == nil {
+		t.Fatal("EncryptAll with the wrong key did not fail")
+	}
+	c.db.View(func(tx Tx) error {
+		if v := tx.Bucket("dev").Get([]byte("KEY")); !bytes.Equal(v, sealed) {
+			t.Error("EncryptAll with the wrong key rewrote a value")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Brian-Kariu/ryuk/config"
+)
+
+var (
+	ErrWorkspaceNotFound = config.ErrWorkspaceNotFound
+	ErrEnvNotFound       = config.ErrEnvNotFound
+	ErrKeyNotFound       = errors.New("key not found")
+	// ErrLocked matches the LockedError returned when another process holds
+	// a workspace.
+	ErrLocked = errors.New("workspace is locked")
+)
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+func envNotFound(env string) error {
+	return fmt.Errorf("%w: %s", ErrEnvNotFound, env)
+}
+
+func keyNotFound(key, env string) error {
+	return fmt.Errorf("%w: %s in environment %s", ErrKeyNotFound, key, env)
+}
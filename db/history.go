@@ -0,0 +1,273 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	historyBucketPrefix = "__history__"
+
+	OpSet      = "set"
+	OpImport   = "import"
+	OpDelete   = "delete"
+	OpRollback = "rollback"
+	OpPromote  = "promote"
+	OpClone    = "clone"
+	// OpBaseline records a value that was stored before history was kept.
+	OpBaseline = "baseline"
+)
+
+// Revision is a single change made to a key.
+type Revision struct {
+	Rev       int       `json:"rev" yaml:"rev"`
+	Value     string    `json:"value" yaml:"value"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	Author    string    `json:"author" yaml:"author"`
+	Operation string    `json:"operation" yaml:"operation"`
+}
+
+func historyBucket(bucket string) string {
+	return historyBucketPrefix + bucket
+}
+
+// historyKey builds the key of a revision. The zero padded revision keeps
+// the revisions of a key sorted in byte order.
+func historyKey(key string, rev int) []byte {
+	return []byte(fmt.Sprintf("%s\x00%020d", key, rev))
+}
+
+func historyPrefix(key string) []byte {
+	return []byte(key + "\x00")
+}
+
+var (
+	authorOnce sync.Once
+	author     string
+)
+
+// currentAuthor returns the git user name when one is configured, falling
+// back to the OS user. It is resolved once per process.
+func currentAuthor() string {
+	authorOnce.Do(func() {
+		author = gitUserName()
+		if author == "" {
+			author = os.Getenv("USER")
+		}
+		if author == "" {
+			if u, err := user.Current(); err == nil {
+				author = u.Username
+			}
+		}
+		if author == "" {
+			author = "unknown"
+		}
+	})
+	return author
+}
+
+// gitUserName reads user.name from the global git config files instead of
+// running git, which may be missing or slow. Repository config and
+// included files are not read.
+func gitUserName() string {
+	if name := os.Getenv("GIT_AUTHOR_NAME"); name != "" {
+		return name
+	}
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	name := ""
+	// git reads both files and the last one wins.
+	for _, path := range []string{filepath.Join(xdg, "git", "config"), filepath.Join(home, ".gitconfig")} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		section := ""
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "" || line[0] == '#' || line[0] == ';':
+			case line[0] == '[':
+				section = strings.ToLower(strings.Trim(line, "[] \t"))
+			case section == "user":
+				k, v, ok := strings.Cut(line, "=")
+				if ok && strings.EqualFold(strings.TrimSpace(k), "name") {
+					name = strings.Trim(strings.TrimSpace(v), `"`)
+				}
+			}
+		}
+	}
+	return name
+}
+
+func (c client) readRevisions(tx Tx, bucket, key string) ([]Revision, error) {
+	revisions := []Revision{}
+	h := tx.Bucket(historyBucket(bucket))
+	if h == nil {
+		return revisions, nil
+	}
+
+	err := h.ForEachPrefix(historyPrefix(key), func(k, v []byte) error {
+		data, err := c.openValue(v, historyBucket(bucket), string(k))
+		if err != nil {
+			return err
+		}
+		var revision Revision
+		if err := json.Unmarshal(data, &revision); err != nil {
+			return fmt.Errorf("corrupt revision %q: %v", k, err)
+		}
+		revisions = append(revisions, revision)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (c client) putRevision(h Bucket, bucket, key string, revision Revision) error {
+	data, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+	k := historyKey(key, revision.Rev)
+	sealed, err := c.sealValue(data, historyBucket(bucket), string(k))
+	if err != nil {
+		return err
+	}
+	return h.Put(k, sealed)
+}
+
+// recordHistory appends a revision for key. previous is the value stored
+// before this change and is kept as a baseline revision when the key has no
+// history yet, so values written by older versions are not lost.
+func (c client) recordHistory(tx Tx, bucket, key string, previous []byte, value, operation string) error {
+	h, err := tx.CreateBucketIfNotExists(historyBucket(bucket))
+	if err != nil {
+		return fmt.Errorf("Error creating history bucket: %v", err)
+	}
+	revisions, err := c.readRevisions(tx, bucket, key)
+	if err != nil {
+		return err
+	}
+
+	rev := len(revisions)
+	if rev == 0 && previous != nil {
+		rev++
+		baseline := Revision{Rev: rev, Value: string(previous), Author: "unknown", Operation: OpBaseline}
+		if err := c.putRevision(h, bucket, key, baseline); err != nil {
+			return err
+		}
+	} else if rev > 0 {
+		rev = revisions[len(revisions)-1].Rev
+	}
+
+	revision := Revision{
+		Rev:       rev + 1,
+		Value:     value,
+		Timestamp: time.Now().UTC(),
+		Author:    currentAuthor(),
+		Operation: operation,
+	}
+	return c.putRevision(h, bucket, key, revision)
+}
+
+// History returns every recorded revision of key, oldest first.
+func (c client) History(bucket, key string) ([]Revision, error) {
+	var revisions []Revision
+	err := c.db.View(func(tx Tx) error {
+		if tx.Bucket(bucket) == nil {
+			return envNotFound(bucket)
+		}
+		var err error
+		revisions, err = c.readRevisions(tx, bucket, key)
+		return err
+	})
+	return revisions, err
+}
+
+// Rollback restores key to the value it had at revision rev. Rolling back
+// to a delete removes the key. The rollback itself is recorded as a new
+// revision.
+func (c client) Rollback(bucket, key string, rev int) (Revision, error) {
+	var target Revision
+	err := c.db.Update(func(tx Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return envNotFound(bucket)
+		}
+		revisions, err := c.readRevisions(tx, bucket, key)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, revision := range revisions {
+			if revision.Rev == rev {
+				target = revision
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("revision %d of %s not found", rev, key)
+		}
+
+		previous, err := c.readRecord(tx, b.Get([]byte(key)), bucket, key)
+		if err != nil {
+			return err
+		}
+		if target.Operation == OpDelete {
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+			if err := deleteLegacySecretFlag(tx, bucket, key); err != nil {
+				return err
+			}
+			return c.recordHistory(tx, bucket, key, previous.value(), "", OpDelete)
+		}
+
+		data := Config{Key: []byte(key), Value: []byte(target.Value)}
+		sealed, err := c.sealRecord(data.record(previous), bucket, key)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(key), sealed); err != nil {
+			return err
+		}
+		return c.recordHistory(tx, bucket, key, previous.value(), target.Value, OpRollback)
+	})
+	return target, err
+}
+
+// openValue decrypts the value stored under key in bucket, keeping nil for
+// missing keys. Until the database is marked as encrypted, values that do
+// not look sealed are plaintext written by older versions. The result never
+// aliases the store's memory so it stays valid after later writes in the
+// same transaction.
+func (c client) openValue(stored []byte, bucket, key string) ([]byte, error) {
+	if stored == nil {
+		return nil, nil
+	}
+	if !c.encrypted && !isSealed(stored) {
+		return append([]byte{}, stored...), nil
+	}
+	value, err := c.sealer.open(stored, valueAAD(bucket, key))
+	if err != nil && !c.encrypted {
+		return nil, fmt.Errorf("%v; run ryuk workspace encrypt if it was stored in plaintext", err)
+	}
+	return value, err
+}
+
+// sealValue encrypts value for storage under key in bucket.
+func (c client) sealValue(value []byte, bucket, key string) ([]byte, error) {
+	return c.sealer.seal(value, valueAAD(bucket, key))
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// values returns the value of every revision of key.
+func values(t *testing.T, c *client, bucket, key string) []string {
+	t.Helper()
+	revisions, err := c.History(bucket, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := []string{}
+	for _, r := range revisions {
+		values = append(values, r.Value)
+	}
+	return values
+}
+
+func TestHistory(t *testing.T) {
+	c := newTestClient(t, "dev")
+	set(t, c, "dev", map[string]string{"HOST": "localhost"})
+	set(t, c, "dev", map[string]string{"HOST": "db.internal"})
+	if err := c.DeleteKey("dev", "HOST"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := operations(t, c, "dev", "HOST"), []string{OpSet, OpSet, OpDelete}; !reflect.DeepEqual(got, want) {
+		t.Errorf("operations = %v, want %v", got, want)
+	}
+	if got, want := values(t, c, "dev", "HOST"), []string{"localhost", "db.internal", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+	if _, err := c.History("prod", "HOST"); !errors.Is(err, ErrEnvNotFound) {
+		t.Errorf("History(prod) returned %v, want %v", err, ErrEnvNotFound)
+	}
+}
+
+func TestHistoryBaseline(t *testing.T) {
+	c := newTestClient(t, "dev")
+	// Written by a version that kept no history.
+	err := c.db.Update(func(tx Tx) error {
+		sealed, err := c.sealValue([]byte("old"), "dev", "HOST")
+		if err != nil {
+			return err
+		}
+		return tx.Bucket("dev").Put([]byte("HOST"), sealed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	set(t, c, "dev", map[string]string{"HOST": "new"})
+
+	if got, want := operations(t, c, "dev", "HOST"), []string{OpBaseline, OpSet}; !reflect.DeepEqual(got, want) {
+		t.Errorf("operations = %v, want %v", got, want)
+	}
+	if got, want := values(t, c, "dev", "HOST"), []string{"old", "new"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	c := newTestClient(t, "dev")
+	set(t, c, "dev", map[string]string{"HOST": "localhost"})
+	set(t, c, "dev", map[string]string{"HOST": "db.internal"})
+
+	target, err := c.Rollback("dev", "HOST", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Value != "localhost" {
+		t.Errorf("Rollback returned revision %+v, want the value localhost", target)
+	}
+	if value, err := c.GetKey("dev", "HOST"); err != nil || value != "localhost" {
+		t.Errorf("HOST = %q, %v; want localhost", value, err)
+	}
+	if got, want := operations(t, c, "dev", "HOST"), []string{OpSet, OpSet, OpRollback}; !reflect.DeepEqual(got, want) {
+		t.Errorf("operations = %v, want %v", got, want)
+	}
+
+	// Rolling back to a delete removes the key.
+	if err := c.DeleteKey("dev", "HOST"); err != nil {
+		t.Fatal(err)
+	}
+	set(t, c, "dev", map[string]string{"HOST": "again"})
+	if _, err := c.Rollback("dev", "HOST", 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetKey("dev", "HOST"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("GetKey after rolling back to a delete returned %v, want %v", err, ErrKeyNotFound)
+	}
+	if got, want := operations(t, c, "dev", "HOST"), []string{OpSet, OpSet, OpRollback, OpDelete, OpSet, OpDelete}; !reflect.DeepEqual(got, want) {
+		t.Errorf("operations = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		bucket  string
+		rev     int
+		wantErr error
+	}{
+		{"missing revision", "dev", 42, nil},
+		{"missing environment", "prod", 1, ErrEnvNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Rollback(tt.bucket, "HOST", tt.rev)
+			if err == nil {
+				t.Fatal("Rollback did not fail")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Rollback returned %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHistorySealed(t *testing.T) {
+	c := newTestClient(t, "dev")
+	set(t, c, "dev", map[string]string{"HOST": "localhost", "PORT": "5432"})
+
+	err := c.db.View(func(tx Tx) error {
+		h := tx.Bucket(historyBucket("dev"))
+		if h == nil {
+			t.Fatal("history bucket was not created")
+		}
+		other := historyKey("PORT", 1)
+		return h.ForEachPrefix(historyPrefix("HOST"), func(k, v []byte) error {
+			if !isSealed(v) {
+				t.Errorf("revision %q is stored in plaintext", k)
+			}
+			if _, err := c.sealer.open(v, valueAAD(historyBucket("dev"), string(k))); err != nil {
+				t.Errorf("revision %q does not open: %v", k, err)
+			}
+			// A revision copied over another one is rejected.
+			if _, err := c.sealer.open(v, valueAAD(historyBucket("dev"), string(other))); err == nil {
+				t.Errorf("revision %q opens as %q", k, other)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitUserName(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_AUTHOR_NAME", "")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := gitUserName(); got != "" {
+		t.Errorf("gitUserName without a config = %q, want none", got)
+	}
+	write(filepath.Join(home, ".config", "git", "config"), "[user]\n\tname = Xdg User\n")
+	if got := gitUserName(); got != "Xdg User" {
+		t.Errorf("gitUserName = %q, want Xdg User", got)
+	}
+	write(filepath.Join(home, ".gitconfig"), "# settings\n[core]\n\tname = editor\n[User]\n\temail = a@b.c\n\tName = \"Ada Lovelace\"\n")
+	if got := gitUserName(); got != "Ada Lovelace" {
+		t.Errorf("gitUserName = %q, want Ada Lovelace", got)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Grace Hopper")
+	if got := gitUserName(); got != "Grace Hopper" {
+		t.Errorf("gitUserName = %q, want Grace Hopper", got)
+	}
+}
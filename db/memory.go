@@ -0,0 +1,190 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	memoryMu     sync.Mutex
+	memoryStores = map[string]*memoryStore{}
+)
+
+// memoryStore keeps a workspace in memory. The CLI can not create one since
+// it would lose its data between runs; it is meant for programs using the Go
+// SDK. Stores are shared by path so reopening a workspace sees the data
+// written before, for as long as one of the handles on it is open.
+type memoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+	path    string
+	// refs counts the open handles, guarded by memoryMu.
+	refs int
+}
+
+// memoryHandle is an open memoryStore. The store is dropped when its last
+// handle is closed.
+type memoryHandle struct {
+	*memoryStore
+	closed bool
+}
+
+func openMemory(path string) *memoryHandle {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	s, ok := memoryStores[path]
+	if !ok {
+		s = &memoryStore{buckets: map[string]map[string][]byte{}, path: path}
+		memoryStores[path] = s
+	}
+	s.refs++
+	return &memoryHandle{memoryStore: s}
+}
+
+func (h *memoryHandle) Close() error {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	h.refs--
+	if h.refs == 0 {
+		delete(memoryStores, h.path)
+	}
+	return nil
+}
+
+// Update runs fn against a copy of the data that replaces it on success.
+func (s *memoryStore) Update(fn func(Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buckets := make(map[string]map[string][]byte, len(s.buckets))
+	for name, contents := range s.buckets {
+		copied := make(map[string][]byte, len(contents))
+		for k, v := range contents {
+			copied[k] = v
+		}
+		buckets[name] = copied
+	}
+	if err := fn(&memoryTx{buckets: buckets, writable: true}); err != nil {
+		return err
+	}
+	s.buckets = buckets
+	return nil
+}
+
+func (s *memoryStore) View(fn func(Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&memoryTx{buckets: s.buckets})
+}
+
+type memoryTx struct {
+	buckets  map[string]map[string][]byte
+	writable bool
+}
+
+var errReadOnly = fmt.Errorf("transaction is read-only")
+
+func (t *memoryTx) Bucket(name string) Bucket {
+	if _, ok := t.buckets[name]; !ok {
+		return nil
+	}
+	return memoryBucket{tx: t, name: name}
+}
+
+func (t *memoryTx) CreateBucket(name string) (Bucket, error) {
+	if !t.writable {
+		return nil, errReadOnly
+	}
+	if _, ok := t.buckets[name]; ok {
+		return nil, fmt.Errorf("bucket already exists")
+	}
+	t.buckets[name] = map[string][]byte{}
+	return memoryBucket{tx: t, name: name}, nil
+}
+
+func (t *memoryTx) CreateBucketIfNotExists(name string) (Bucket, error) {
+	if b := t.Bucket(name); b != nil {
+		return b, nil
+	}
+	return t.CreateBucket(name)
+}
+
+func (t *memoryTx) DeleteBucket(name string) error {
+	if !t.writable {
+		return errReadOnly
+	}
+	if _, ok := t.buckets[name]; !ok {
+		return fmt.Errorf("bucket not found")
+	}
+	delete(t.buckets, name)
+	return nil
+}
+
+func (t *memoryTx) ForEach(fn func(name string, b Bucket) error) error {
+	names := make([]string, 0, len(t.buckets))
+	for name := range t.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn(name, memoryBucket{tx: t, name: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryBucket struct {
+	tx   *memoryTx
+	name string
+}
+
+func (b memoryBucket) Get(key []byte) []byte {
+	return b.tx.buckets[b.name][string(key)]
+}
+
+func (b memoryBucket) Put(key, value []byte) error {
+	if !b.tx.writable {
+		return errReadOnly
+	}
+	b.tx.buckets[b.name][string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (b memoryBucket) Delete(key []byte) error {
+	if !b.tx.writable {
+		return errReadOnly
+	}
+	delete(b.tx.buckets[b.name], string(key))
+	return nil
+}
+
+func (b memoryBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.ForEachPrefix(nil, fn)
+}
+
+func (b memoryBucket) ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error {
+	contents := b.tx.buckets[b.name]
+	keys := []string{}
+	for k := range contents {
+		if bytes.HasPrefix([]byte(k), prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn([]byte(k), contents[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b memoryBucket) Len() int {
+	return len(b.tx.buckets[b.name])
+}
@@ -0,0 +1,40 @@
+package db
+
+import "testing"
+
+func TestMemoryStoreRelease(t *testing.T) {
+	path := t.Name()
+	first := openMemory(path)
+	second := openMemory(path)
+	if err := first.Update(func(tx Tx) error {
+		_, err := tx.CreateBucket("dev")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	first.Close()
+	first.Close()
+	second.View(func(tx Tx) error {
+		if tx.Bucket("dev") == nil {
+			t.Error("data was dropped while a handle was open")
+		}
+		return nil
+	})
+
+	second.Close()
+	memoryMu.Lock()
+	_, kept := memoryStores[path]
+	memoryMu.Unlock()
+	if kept {
+		t.Fatal("store was kept after its last handle was closed")
+	}
+	reopened := openMemory(path)
+	defer reopened.Close()
+	reopened.View(func(tx Tx) error {
+		if tx.Bucket("dev") != nil {
+			t.Error("reopening a released store returned the old data")
+		}
+		return nil
+	})
+}
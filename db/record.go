@@ -0,0 +1,160 @@
+package db
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recordPrefix marks a stored record. Values without it were written by
+// versions of ryuk that only kept the value itself.
+const recordPrefix = "ryuk:record:v1:"
+
+// Record is what is stored for each variable.
+type Record struct {
+	Value       string    `json:"value" yaml:"value"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string  `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Owner       string    `json:"owner,omitempty" yaml:"owner,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+	// Secret is set when the key was classified explicitly with --secret.
+	Secret *bool `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// HasTag reports whether the record is tagged with tag.
+func (r Record) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeRecord(r Record) ([]byte, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(recordPrefix), data...), nil
+}
+
+// decodeRecord parses a stored record. A bare value is returned as a record
+// without metadata, including one that merely starts like a record.
+func decodeRecord(data []byte) Record {
+	if !strings.HasPrefix(string(data), recordPrefix) {
+		return Record{Value: string(data)}
+	}
+	r := Record{}
+	if err := json.Unmarshal(data[len(recordPrefix):], &r); err != nil {
+		return Record{Value: string(data)}
+	}
+	return r
+}
+
+// readRecord decrypts and parses a stored record, returning nil for missing
+// keys.
+func (c client) readRecord(tx Tx, stored []byte, bucket, key string) (*Record, error) {
+	if stored == nil {
+		return nil, nil
+	}
+	r, err := c.openRecord(tx, stored, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// openRecord is readRecord for values visited with ForEach, where an empty
+// value written by older versions can show up as nil.
+func (c client) openRecord(tx Tx, stored []byte, bucket, key string) (Record, error) {
+	data, err := c.openValue(stored, bucket, key)
+	if err != nil {
+		return Record{}, err
+	}
+	r := decodeRecord(data)
+	if r.Secret == nil {
+		r.Secret, err = c.legacySecretFlag(tx, bucket, key)
+	}
+	return r, err
+}
+
+// legacySecretBucket held the keys of bucket classified with --secret
+// before the flag moved into the record. It is still read for records
+// written back then, and moved into them when they are next written.
+func legacySecretBucket(bucket string) string {
+	return "__secrets__" + bucket
+}
+
+func (c client) legacySecretFlag(tx Tx, bucket, key string) (*bool, error) {
+	b := tx.Bucket(legacySecretBucket(bucket))
+	if b == nil {
+		return nil, nil
+	}
+	value, err := c.openValue(b.Get([]byte(key)), legacySecretBucket(bucket), key)
+	if value == nil || err != nil {
+		return nil, err
+	}
+	secret, err := strconv.ParseBool(string(value))
+	if err != nil {
+		return nil, nil
+	}
+	return &secret, nil
+}
+
+// deleteLegacySecretFlag drops the flag of a deleted key, so that a key
+// set again later is classified afresh.
+func deleteLegacySecretFlag(tx Tx, bucket, key string) error {
+	b := tx.Bucket(legacySecretBucket(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.Delete([]byte(key))
+}
+
+func (c client) sealRecord(r Record, bucket, key string) ([]byte, error) {
+	data, err := encodeRecord(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.sealValue(data, bucket, key)
+}
+
+// value returns the value of a record read with readRecord, or nil when
+// the key was missing.
+func (r *Record) value() []byte {
+	if r == nil {
+		return nil
+	}
+	return []byte(r.Value)
+}
+
+// record builds what is stored for data. Metadata that data does not set is
+// kept from previous, the record being replaced, if there is one.
+func (data Config) record(previous *Record) Record {
+	now := time.Now().UTC()
+	r := Record{CreatedAt: now, Owner: currentAuthor()}
+	if previous != nil {
+		r = *previous
+		if r.CreatedAt.IsZero() {
+			r.CreatedAt = now
+		}
+	}
+	r.Value = string(data.Value)
+	r.UpdatedAt = now
+	if data.Description != nil {
+		r.Description = *data.Description
+	}
+	if data.Tags != nil {
+		r.Tags = data.Tags
+	}
+	if data.Owner != nil {
+		r.Owner = *data.Owner
+	}
+	if data.Secret != nil {
+		r.Secret = data.Secret
+	}
+	return r
+}
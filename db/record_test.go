@@ -0,0 +1,66 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeRecord(t *testing.T) {
+	yes := true
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		data string
+		want Record
+	}{
+		{
+			name: "bare value",
+			data: "postgres://localhost",
+			want: Record{Value: "postgres://localhost"},
+		},
+		{
+			name: "empty bare value",
+			data: "",
+			want: Record{},
+		},
+		{
+			name: "bare json",
+			data: `{"value":"x"}`,
+			want: Record{Value: `{"value":"x"}`},
+		},
+		{
+			name: "record",
+			data: recordPrefix + `{"value":"x","description":"d","tags":["a"],"created_at":"2024-05-01T12:00:00Z","secret":true}`,
+			want: Record{Value: "x", Description: "d", Tags: []string{"a"}, CreatedAt: created, Secret: &yes},
+		},
+		{
+			name: "prefix without json",
+			data: recordPrefix + "not json",
+			want: Record{Value: recordPrefix + "not json"},
+		},
+		{
+			name: "prefix alone",
+			data: recordPrefix,
+			want: Record{Value: recordPrefix},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeRecord([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeRecord(%q) = %+v, want %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRecord(t *testing.T) {
+	r := Record{Value: "x", Tags: []string{"a", "b"}, Owner: "ops"}
+	data, err := encodeRecord(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeRecord(data); !reflect.DeepEqual(got, r) {
+		t.Errorf("decodeRecord(encodeRecord(r)) = %+v, want %+v", got, r)
+	}
+}
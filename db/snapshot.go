@@ -0,0 +1,243 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Brian-Kariu/ryuk/config"
+)
+
+// Snapshot holds the raw contents of an environment's buckets. Values are
+// kept exactly as stored, so sealed values stay encrypted on disk.
+type Snapshot struct {
+	Env       string                       `json:"env"`
+	CreatedAt time.Time                    `json:"created_at"`
+	Buckets   map[string]map[string][]byte `json:"buckets"`
+	// Config is the workspace config of the environment, such as the
+	// environment it extends.
+	Config config.EnvironmentConfig `json:"config"`
+}
+
+// envBuckets returns the bucket of an environment followed by the internal
+// buckets that belong to it.
+func envBuckets(env string) []string {
+	return []string{env, historyBucket(env), legacySecretBucket(env)}
+}
+
+func snapshotBuckets(tx Tx, env string) Snapshot {
+	snapshot := Snapshot{Env: env, CreatedAt: time.Now().UTC(), Buckets: map[string]map[string][]byte{}}
+	for _, name := range envBuckets(env) {
+		b := tx.Bucket(name)
+		if b == nil {
+			continue
+		}
+		contents := map[string][]byte{}
+		b.ForEach(func(k, v []byte) error {
+			contents[string(k)] = append([]byte{}, v...)
+			return nil
+		})
+		snapshot.Buckets[name] = contents
+	}
+	return snapshot
+}
+
+// DeleteBucket removes an environment and its internal buckets. Unless force
+// is set it refuses to delete an environment that still holds variables.
+// keep is called with a snapshot of the deleted data before anything is
+// removed; if it fails the delete is rolled back. A missing bucket is not
+// an error so stale environments can still be cleaned up.
+func (c client) DeleteBucket(env string, force bool, keep func(Snapshot) error) error {
+	err := c.db.Update(func(tx Tx) error {
+		b := tx.Bucket(env)
+		if b == nil {
+			return nil
+		}
+		if count := b.Len(); count > 0 && !force {
+			return fmt.Errorf("environment %s has %d variables, use --force to delete it anyway", env, count)
+		}
+
+		if err := keep(snapshotBuckets(tx, env)); err != nil {
+			return fmt.Errorf("Error saving snapshot: %v", err)
+		}
+		for _, name := range envBuckets(env) {
+			if tx.Bucket(name) == nil {
+				continue
+			}
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// CloneBucket creates dst with a copy of every variable in src, metadata
+// included. The copies start a fresh history in dst.
+func (c client) CloneBucket(src, dst string) (int, error) {
+	count := 0
+	err := c.db.Update(func(tx Tx) error {
+		from := tx.Bucket(src)
+		if from == nil {
+			return envNotFound(src)
+		}
+		if tx.Bucket(dst) != nil {
+			return fmt.Errorf("environment %s already exists", dst)
+		}
+		to, err := tx.CreateBucket(dst)
+		if err != nil {
+			return err
+		}
+
+		records := map[string]Record{}
+		err = from.ForEach(func(k, v []byte) error {
+			r, err := c.openRecord(tx, v, src, string(k))
+			if err != nil {
+				return fmt.Errorf("key %s: %v", k, err)
+			}
+			records[string(k)] = r
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for k, r := range records {
+			sealed, err := c.sealRecord(r, dst, k)
+			if err != nil {
+				return err
+			}
+			if err := to.Put([]byte(k), sealed); err != nil {
+				return err
+			}
+			if err := c.recordHistory(tx, dst, k, nil, r.Value, OpClone); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// RenameBucket moves an environment and its internal buckets to a new name
+// in a single transaction. Values and history are copied as stored.
+func (c client) RenameBucket(env, newEnv string) error {
+	err := c.db.Update(func(tx Tx) error {
+		if tx.Bucket(env) == nil {
+			return envNotFound(env)
+		}
+		if tx.Bucket(newEnv) != nil {
+			return fmt.Errorf("environment %s already exists", newEnv)
+		}
+		targets := envBuckets(newEnv)
+		for i, name := range envBuckets(env) {
+			from := tx.Bucket(name)
+			if from == nil {
+				continue
+			}
+			to, err := tx.CreateBucket(targets[i])
+			if err != nil {
+				return err
+			}
+			// Values are bound to their bucket, so they are sealed again
+			// under the new name.
+			err = from.ForEach(func(k, v []byte) error {
+				value, err := c.openValue(v, name, string(k))
+				if err != nil {
+					return fmt.Errorf("key %s: %v", k, err)
+				}
+				sealed, err := c.sealValue(value, targets[i], string(k))
+				if err != nil {
+					return err
+				}
+				return to.Put(append([]byte{}, k...), sealed)
+			})
+			if err != nil {
+				return err
+			}
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// RestoreSnapshot recreates the buckets saved in snapshot. It fails if the
+// environment exists again.
+func (c client) RestoreSnapshot(snapshot Snapshot) error {
+	err := c.db.Update(func(tx Tx) error {
+		if tx.Bucket(snapshot.Env) != nil {
+			return fmt.Errorf("environment %s already exists", snapshot.Env)
+		}
+		for name, contents := range snapshot.Buckets {
+			b, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+			for k, v := range contents {
+				if err := b.Put([]byte(k), v); err != nil {
+					return err
+				}
+			}
+		}
+		// An empty environment has no entry in the snapshot.
+		_, err := tx.CreateBucketIfNotExists(snapshot.Env)
+		return err
+	})
+	return err
+}
+
+// WriteSnapshot stores snapshot as a file in dir and returns its path.
+func WriteSnapshot(dir string, snapshot Snapshot) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s.json", snapshot.Env, snapshot.CreatedAt.Format("20060102T150405.000000000"))
+	path := filepath.Join(dir, name)
+	return path, os.WriteFile(path, data, 0600)
+}
+
+func ReadSnapshot(path string) (Snapshot, error) {
+	snapshot := Snapshot{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("%s is not a valid snapshot: %v", path, err)
+	}
+	return snapshot, nil
+}
+
+// LatestSnapshot returns the path of the most recent snapshot of env in dir.
+func LatestSnapshot(dir, env string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, env+"-*.json"))
+	if err != nil {
+		return "", err
+	}
+	// The glob also matches environments whose name starts with env-.
+	candidates := []string{}
+	for _, match := range matches {
+		rest := strings.TrimPrefix(filepath.Base(match), env+"-")
+		if !strings.Contains(rest, "-") {
+			candidates = append(candidates, match)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no snapshot found for environment %s", env)
+	}
+	sort.Strings(candidates)
+	return candidates[len(candidates)-1], nil
+}
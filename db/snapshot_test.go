@@ -0,0 +1,266 @@
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCloneBucket(t *testing.T) {
+	c := newTestClient(t, "dev", "prod")
+	set(t, c, "dev", map[string]string{"HOST": "localhost", "URL": "http://${HOST}"})
+	description := "where to connect"
+	if err := c.AddKey("dev", Config{Key: []byte("HOST"), Value: []byte("localhost"), Description: &description}); err != nil {
+		t.Fatal(err)
+	}
+	set(t, c, GlobalBucket, map[string]string{"REGION": "eu"})
+
+	count, err := c.CloneBucket("dev", "stg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("CloneBucket copied %d variables, want 2", count)
+	}
+
+	vars, err := c.ResolveVars([]string{"stg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"HOST": "localhost", "URL": "http://${HOST}", "REGION": "eu"}
+	got := map[string]string{}
+	for k, v := range vars {
+		got[k] = v.Value
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cloned variables = %v, want %v", got, want)
+	}
+	if vars["HOST"].Layer != "stg" || vars["REGION"].Layer != GlobalBucket {
+		t.Errorf("layers = %s, %s; want stg, %s", vars["HOST"].Layer, vars["REGION"].Layer, GlobalBucket)
+	}
+	if vars["HOST"].Record.Description != description {
+		t.Errorf("description = %q, want %q", vars["HOST"].Record.Description, description)
+	}
+	// The copies start a history of their own.
+	if got, want := operations(t, c, "stg", "HOST"), []string{OpClone}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stg history = %v, want %v", got, want)
+	}
+	// The source is left alone.
+	if value, err := c.GetKey("dev", "HOST"); err != nil || value != "localhost" {
+		t.Errorf("dev HOST = %q, %v", value, err)
+	}
+
+	tests := []struct {
+		name     string
+		src, dst string
+		wantErr  error
+	}{
+		{"missing source", "qa", "qa2", ErrEnvNotFound},
+		{"existing target", "dev", "prod", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.CloneBucket(tt.src, tt.dst)
+			if err == nil {
+				t.Fatal("CloneBucket did not fail")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("CloneBucket returned %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenameBucket(t *testing.T) {
+	c := newTestClient(t, "dev", "prod")
+	set(t, c, "dev", map[string]string{"HOST": "localhost"})
+	set(t, c, "dev", map[string]string{"HOST": "db.internal", "PORT": "5432"})
+	legacy := legacySecretBucket("dev")
+	err := c.db.Update(func(tx Tx) error {
+		b, err := tx.CreateBucket(legacy)
+		if err != nil {
+			return err
+		}
+		sealed, err := c.sealValue([]byte("s3cret"), legacy, "TOKEN")
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("TOKEN"), sealed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := operations(t, c, "dev", "HOST")
+
+	if err := c.RenameBucket("dev", "stg"); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := c.GetKey("stg", "HOST"); err != nil || value != "db.internal" {
+		t.Errorf("stg HOST = %q, %v; want db.internal", value, err)
+	}
+	if got := operations(t, c, "stg", "HOST"); !reflect.DeepEqual(got, before) {
+		t.Errorf("stg history = %v, want %v", got, before)
+	}
+	err = c.db.View(func(tx Tx) error {
+		for _, name := range envBuckets("dev") {
+			if tx.Bucket(name) != nil {
+				t.Errorf("bucket %s is still there", name)
+			}
+		}
+		// Every value is bound to its new bucket and no longer opens
+		// under the old one.
+		for i, name := range envBuckets("stg") {
+			b := tx.Bucket(name)
+			if b == nil {
+				t.Errorf("bucket %s was not created", name)
+				continue
+			}
+			old := envBuckets("dev")[i]
+			b.ForEach(func(k, v []byte) error {
+				if _, err := c.sealer.open(v, valueAAD(name, string(k))); err != nil {
+					t.Errorf("%s/%s does not open under its new name: %v", name, k, err)
+				}
+				if _, err := c.sealer.open(v, valueAAD(old, string(k))); err == nil {
+					t.Errorf("%s/%s still opens under %s", name, k, old)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		env, newEnv string
+		wantErr     error
+	}{
+		{"missing environment", "dev", "qa", ErrEnvNotFound},
+		{"existing target", "stg", "prod", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.RenameBucket(tt.env, tt.newEnv)
+			if err == nil {
+				t.Fatal("RenameBucket did not fail")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("RenameBucket returned %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	// A failed rename leaves the environment in place.
+	if value, err := c.GetKey("stg", "PORT"); err != nil || value != "5432" {
+		t.Errorf("stg PORT = %q, %v; want 5432", value, err)
+	}
+}
+
+func TestDeleteRestore(t *testing.T) {
+	c := newTestClient(t, "dev", "dev-eu", "empty")
+	set(t, c, "dev", map[string]string{"HOST": "localhost"})
+	set(t, c, "dev", map[string]string{"HOST": "db.internal", "PORT": "5432"})
+	set(t, c, "dev-eu", map[string]string{"HOST": "eu.internal"})
+	dir := t.TempDir()
+	keep := func(snapshot Snapshot) error {
+		_, err := WriteSnapshot(dir, snapshot)
+		return err
+	}
+	exists := func(env string) bool {
+		_, err := c.ListVars(env)
+		return err == nil
+	}
+
+	// Environments that still hold variables need force.
+	if err := c.DeleteBucket("dev", false, keep); err == nil {
+		t.Fatal("deleting dev without force did not fail")
+	}
+	// The delete is rolled back when the snapshot cannot be kept.
+	failed := errors.New("disk full")
+	err := c.DeleteBucket("dev", true, func(Snapshot) error { return failed })
+	if err == nil || !strings.Contains(err.Error(), failed.Error()) {
+		t.Fatalf("DeleteBucket returned %v, want the keep error", err)
+	}
+	if !exists("dev") {
+		t.Fatal("dev was deleted although its snapshot was not kept")
+	}
+	if _, err := LatestSnapshot(dir, "dev"); err == nil {
+		t.Error("LatestSnapshot found a snapshot before anything was deleted")
+	}
+
+	if err := c.DeleteBucket("dev", true, keep); err != nil {
+		t.Fatal(err)
+	}
+	if exists("dev") {
+		t.Fatal("dev was not deleted")
+	}
+	if got := operations(t, c, "dev-eu", "HOST"); len(got) != 1 {
+		t.Errorf("dev-eu history = %v, want it left alone", got)
+	}
+	first, err := LatestSnapshot(dir, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := func(path string) {
+		t.Helper()
+		snapshot, err := ReadSnapshot(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.RestoreSnapshot(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restore(first)
+	if value, err := c.GetKey("dev", "HOST"); err != nil || value != "db.internal" {
+		t.Errorf("restored HOST = %q, %v; want db.internal", value, err)
+	}
+	if got, want := operations(t, c, "dev", "HOST"), []string{OpSet, OpSet}; !reflect.DeepEqual(got, want) {
+		t.Errorf("restored history = %v, want %v", got, want)
+	}
+	if err := c.RestoreSnapshot(Snapshot{Env: "dev"}); err == nil {
+		t.Error("restoring over an existing environment did not fail")
+	}
+
+	// The latest snapshot wins unless another one is picked.
+	set(t, c, "dev", map[string]string{"HOST": "changed"})
+	if err := c.DeleteBucket("dev", true, keep); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := LatestSnapshot(dir, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest == first {
+		t.Fatalf("LatestSnapshot = %s, want the second snapshot", latest)
+	}
+	restore(first)
+	if value, err := c.GetKey("dev", "HOST"); err != nil || value != "db.internal" {
+		t.Errorf("HOST restored from %s = %q, %v; want db.internal", filepath.Base(first), value, err)
+	}
+
+	// An empty environment is deleted without force and comes back empty.
+	if err := c.DeleteBucket("empty", false, keep); err != nil {
+		t.Fatal(err)
+	}
+	empty, err := LatestSnapshot(dir, "empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore(empty)
+	if vars, err := c.ListVars("empty"); err != nil || len(vars) != 0 {
+		t.Errorf("restored empty = %v, %v", vars, err)
+	}
+
+	// Snapshots of dev-eu are not taken for dev.
+	if err := c.DeleteBucket("dev-eu", true, keep); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LatestSnapshot(dir, "dev"); err != nil || got != latest {
+		t.Errorf("LatestSnapshot(dev) = %s, %v; want %s", got, err, latest)
+	}
+}
@@ -0,0 +1,304 @@
+//go:build cgo
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS buckets (
+	name TEXT PRIMARY KEY
+);
+CREATE TABLE IF NOT EXISTS entries (
+	bucket TEXT NOT NULL REFERENCES buckets(name) ON DELETE CASCADE,
+	key    BLOB NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+);`
+
+// sqliteAvailable is false in builds without cgo, which the SQLite driver
+// needs.
+const sqliteAvailable = true
+
+// sqliteStore keeps a workspace in a SQLite database, with one row per key.
+type sqliteStore struct {
+	db *sql.DB
+	// writer runs updates. Its transactions take the write lock up front,
+	// so two writers wait on the busy timeout instead of one failing when
+	// it first writes. It is nil for read-only stores.
+	writer *sql.DB
+	path   string
+}
+
+// openSQLite opens the database at path. SQLite only locks the file while a
+// transaction runs, so readers and writers wait for each other per
+// transaction rather than for the life of the store.
+func openSQLite(path string, opts Options) (*sqliteStore, error) {
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d&_foreign_keys=on", path, opts.LockTimeout.Milliseconds())
+	if opts.ReadOnly {
+		dsn += "&mode=ro"
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening DB: %v", err)
+	}
+	s := &sqliteStore{db: db, path: path}
+	if opts.ReadOnly {
+		return s, nil
+	}
+	s.writer, err = sql.Open("sqlite3", dsn+"&_txlock=immediate")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Error opening DB: %v", err)
+	}
+	if _, err := s.writer.Exec(sqliteSchema); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("Error opening DB: %v", s.lockError(err))
+	}
+	return s, nil
+}
+
+// lockError turns SQLite's busy errors into a LockedError.
+func (s *sqliteStore) lockError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+		return &LockedError{Path: s.path}
+	}
+	return err
+}
+
+func (s *sqliteStore) run(readOnly bool, fn func(Tx) error) error {
+	db := s.db
+	if !readOnly {
+		if s.writer == nil {
+			return errReadOnly
+		}
+		db = s.writer
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return s.lockError(err)
+	}
+	t := &sqliteTx{tx: tx, writable: !readOnly}
+	if err := fn(t); err != nil || t.err != nil {
+		tx.Rollback()
+		// A failed query is the cause of whatever fn made of it, such as
+		// a bucket that seemed to be missing.
+		if t.err != nil {
+			err = t.err
+		}
+		return s.lockError(err)
+	}
+	if readOnly {
+		return tx.Rollback()
+	}
+	return s.lockError(tx.Commit())
+}
+
+func (s *sqliteStore) Update(fn func(Tx) error) error {
+	return s.run(false, fn)
+}
+
+func (s *sqliteStore) View(fn func(Tx) error) error {
+	return s.run(true, fn)
+}
+
+func (s *sqliteStore) Close() error {
+	err := s.db.Close()
+	if s.writer != nil {
+		if werr := s.writer.Close(); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+type sqliteTx struct {
+	tx       *sql.Tx
+	writable bool
+	// err is the first failed query of a method that can not return it.
+	// The transaction is rolled back and fails with it.
+	err error
+}
+
+func (t *sqliteTx) fail(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *sqliteTx) exists(name string) (bool, error) {
+	var found string
+	err := t.tx.QueryRow(`SELECT name FROM buckets WHERE name = ?`, name).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (t *sqliteTx) Bucket(name string) Bucket {
+	ok, err := t.exists(name)
+	if err != nil {
+		t.fail(err)
+	}
+	if !ok {
+		return nil
+	}
+	return sqliteBucket{tx: t, name: name}
+}
+
+func (t *sqliteTx) CreateBucket(name string) (Bucket, error) {
+	if !t.writable {
+		return nil, errReadOnly
+	}
+	ok, err := t.exists(name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return nil, fmt.Errorf("bucket already exists")
+	}
+	if _, err := t.tx.Exec(`INSERT INTO buckets (name) VALUES (?)`, name); err != nil {
+		return nil, err
+	}
+	return sqliteBucket{tx: t, name: name}, nil
+}
+
+func (t *sqliteTx) CreateBucketIfNotExists(name string) (Bucket, error) {
+	ok, err := t.exists(name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return sqliteBucket{tx: t, name: name}, nil
+	}
+	return t.CreateBucket(name)
+}
+
+func (t *sqliteTx) DeleteBucket(name string) error {
+	if !t.writable {
+		return errReadOnly
+	}
+	result, err := t.tx.Exec(`DELETE FROM buckets WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("bucket not found")
+	}
+	return nil
+}
+
+func (t *sqliteTx) ForEach(fn func(name string, b Bucket) error) error {
+	rows, err := t.tx.Query(`SELECT name FROM buckets ORDER BY name`)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := fn(name, sqliteBucket{tx: t, name: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sqliteBucket struct {
+	tx   *sqliteTx
+	name string
+}
+
+func (b sqliteBucket) Get(key []byte) []byte {
+	var value []byte
+	err := b.tx.tx.QueryRow(`SELECT value FROM entries WHERE bucket = ? AND key = ?`, b.name, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		b.tx.fail(err)
+		return nil
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return value
+}
+
+func (b sqliteBucket) Put(key, value []byte) error {
+	if !b.tx.writable {
+		return errReadOnly
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	_, err := b.tx.tx.Exec(`INSERT INTO entries (bucket, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`, b.name, key, value)
+	return err
+}
+
+func (b sqliteBucket) Delete(key []byte) error {
+	if !b.tx.writable {
+		return errReadOnly
+	}
+	_, err := b.tx.tx.Exec(`DELETE FROM entries WHERE bucket = ? AND key = ?`, b.name, key)
+	return err
+}
+
+func (b sqliteBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.ForEachPrefix(nil, fn)
+}
+
+// ForEachPrefix reads the matching rows before calling fn, so fn can query
+// the database.
+func (b sqliteBucket) ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error {
+	rows, err := b.tx.tx.Query(`SELECT key, value FROM entries
+		WHERE bucket = ? AND substr(key, 1, ?) = ? ORDER BY key`, b.name, len(prefix), append([]byte{}, prefix...))
+	if err != nil {
+		return err
+	}
+	type pair struct{ k, v []byte }
+	pairs := []pair{}
+	for rows.Next() {
+		var p pair
+		if err := rows.Scan(&p.k, &p.v); err != nil {
+			rows.Close()
+			return err
+		}
+		pairs = append(pairs, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		if err := fn(p.k, p.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b sqliteBucket) Len() int {
+	var count int
+	if err := b.tx.tx.QueryRow(`SELECT COUNT(*) FROM entries WHERE bucket = ?`, b.name).Scan(&count); err != nil {
+		b.tx.fail(err)
+	}
+	return count
+}
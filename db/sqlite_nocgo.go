@@ -0,0 +1,11 @@
+//go:build !cgo
+
+package db
+
+import "fmt"
+
+const sqliteAvailable = false
+
+func openSQLite(path string, opts Options) (Store, error) {
+	return nil, fmt.Errorf("the sqlite backend needs ryuk to be built with cgo (CGO_ENABLED=1)")
+}
@@ -0,0 +1,64 @@
+//go:build cgo
+
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteLocking(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app")
+	open := func() Store {
+		t.Helper()
+		s, err := OpenStore(BackendSQLite, path, Options{LockTimeout: 50 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+	writer, other := open(), open()
+	if err := writer.Update(func(tx Tx) error {
+		_, err := tx.CreateBucket("dev")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := writer.Update(func(tx Tx) error {
+		// Reads do not take the write lock, so they go ahead.
+		if err := other.View(func(tx Tx) error {
+			if tx.Bucket("dev") == nil {
+				t.Error("bucket dev is missing")
+			}
+			return nil
+		}); err != nil {
+			t.Errorf("View during an update returned %v", err)
+		}
+
+		// A second writer waits for the lock timeout and gives up.
+		start := time.Now()
+		err := other.Update(func(tx Tx) error { return nil })
+		if !errors.Is(err, ErrLocked) {
+			t.Errorf("Update during an update returned %v, want %v", err, ErrLocked)
+		}
+		if waited := time.Since(start); waited < 50*time.Millisecond {
+			t.Errorf("Update gave up after %v, before the lock timeout", waited)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock is released with the transaction.
+	if err := other.Update(func(tx Tx) error {
+		_, err := tx.CreateBucket("prod")
+		return err
+	}); err != nil {
+		t.Errorf("Update after the lock was released returned %v", err)
+	}
+}
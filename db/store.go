@@ -0,0 +1,145 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	BackendBolt   = "bolt"
+	BackendMemory = "memory"
+	BackendSQLite = "sqlite"
+)
+
+// Backends lists the storage backends a workspace can be created with.
+// BackendMemory keeps nothing once the process exits, so it is left out and
+// only offered to programs through the Go SDK.
+var Backends = []string{BackendBolt, BackendSQLite}
+
+// Store is the storage engine behind a workspace. Data is kept in named
+// buckets of key/value pairs and every access goes through a transaction.
+type Store interface {
+	// Update runs fn in a read-write transaction. Nothing is written if fn
+	// returns an error.
+	Update(fn func(Tx) error) error
+	// View runs fn in a read-only transaction.
+	View(fn func(Tx) error) error
+	Close() error
+}
+
+// Tx is a transaction on a Store.
+type Tx interface {
+	// Bucket returns the named bucket, or nil if it does not exist.
+	Bucket(name string) Bucket
+	// CreateBucket fails if the bucket already exists.
+	CreateBucket(name string) (Bucket, error)
+	CreateBucketIfNotExists(name string) (Bucket, error)
+	DeleteBucket(name string) error
+	// ForEach calls fn for every bucket, in name order.
+	ForEach(fn func(name string, b Bucket) error) error
+}
+
+// Bucket is a set of key/value pairs. Values returned by Get and ForEach are
+// only valid for the life of the transaction, and a bucket must not be
+// modified from within its own ForEach.
+type Bucket interface {
+	// Get returns nil when key does not exist.
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// ForEach calls fn for every pair in byte order of the keys.
+	ForEach(fn func(k, v []byte) error) error
+	// ForEachPrefix is ForEach limited to the keys starting with prefix.
+	ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error
+	// Len returns the number of keys in the bucket.
+	Len() int
+}
+
+// ValidateBackend returns an error when backend is not one of Backends or
+// can not be used by this build. An empty backend selects bolt.
+func ValidateBackend(backend string) error {
+	switch backend {
+	case "":
+		return nil
+	case BackendMemory:
+		return fmt.Errorf("the memory backend keeps nothing between runs and is only available through the Go SDK, expected one of %s", strings.Join(Backends, ", "))
+	case BackendSQLite:
+		if !sqliteAvailable {
+			return fmt.Errorf("the sqlite backend needs ryuk to be built with cgo (CGO_ENABLED=1)")
+		}
+	}
+	for _, b := range Backends {
+		if b == backend {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown backend %q, expected one of %s", backend, strings.Join(Backends, ", "))
+}
+
+// Options control how a store is opened.
+type Options struct {
+	// ReadOnly stores can be opened by several processes at once, but
+	// refuse writes.
+	ReadOnly bool
+	// LockTimeout is how long to wait for another process to release the
+	// store. Zero fails straight away.
+	LockTimeout time.Duration
+}
+
+// LockedError is returned when a store is held by another process for
+// longer than the lock timeout.
+type LockedError struct {
+	Path string
+	// Workspace is the name of the workspace, when known.
+	Workspace string
+	// PID of the process holding the store, or 0 when it is not known.
+	PID int
+}
+
+func (e *LockedError) Error() string {
+	name := e.Workspace
+	if name == "" {
+		name = e.Path
+	}
+	if e.PID != 0 {
+		return fmt.Sprintf("workspace %s is locked by PID %d", name, e.PID)
+	}
+	return fmt.Sprintf("workspace %s is locked by another process", name)
+}
+
+// OpenStore opens the store kept at path with the given backend.
+func OpenStore(backend, path string, opts Options) (Store, error) {
+	switch backend {
+	case "", BackendBolt:
+		return openBolt(path, opts)
+	case BackendMemory:
+		return openMemory(path), nil
+	case BackendSQLite:
+		return openSQLite(path, opts)
+	}
+	return nil, ValidateBackend(backend)
+}
+
+// MoveStore moves the store kept at path to dst. The store is held for
+// writing during the move, so it fails with a LockedError while another
+// process uses the store and nobody writes to it on its way out. A store
+// that was never written is not an error.
+func MoveStore(backend, path, dst string, opts Options) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	s, err := OpenStore(backend, path, opts)
+	if err != nil {
+		return err
+	}
+	err = s.Update(func(Tx) error {
+		return os.Rename(path, dst)
+	})
+	// Closing also removes the lock file left next to the old path.
+	if cerr := s.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,318 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// openTestStores returns a fresh store of every backend this build has.
+func openTestStores(t *testing.T) map[string]Store {
+	t.Helper()
+	dir := t.TempDir()
+	stores := map[string]Store{}
+	backends := []string{BackendBolt, BackendMemory}
+	if sqliteAvailable {
+		backends = append(backends, BackendSQLite)
+	}
+	for _, backend := range backends {
+		path := filepath.Join(dir, backend)
+		if backend == BackendMemory {
+			path = t.Name() + "/" + path
+		}
+		s, err := OpenStore(backend, path, Options{LockTimeout: 0})
+		if err != nil {
+			t.Fatalf("opening %s store: %v", backend, err)
+		}
+		t.Cleanup(func() { s.Close() })
+		stores[backend] = s
+	}
+	return stores
+}
+
+type pair struct{ k, v string }
+
+func collect(t *testing.T, each func(func(k, v []byte) error) error) []pair {
+	t.Helper()
+	pairs := []pair{}
+	if err := each(func(k, v []byte) error {
+		pairs = append(pairs, pair{string(k), string(v)})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return pairs
+}
+
+func TestStoreBuckets(t *testing.T) {
+	for backend, s := range openTestStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			err := s.Update(func(tx Tx) error {
+				b, err := tx.CreateBucket("dev")
+				if err != nil {
+					return err
+				}
+				for _, p := range []pair{{"b", "2"}, {"a", "1"}, {"ab", "3"}, {"c", "4"}} {
+					if err := b.Put([]byte(p.k), []byte(p.v)); err != nil {
+						return err
+					}
+				}
+				return b.Delete([]byte("c"))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.View(func(tx Tx) error {
+				b := tx.Bucket("dev")
+				if b == nil {
+					t.Fatal("bucket dev is missing")
+				}
+				tests := []struct {
+					key  string
+					want []byte
+				}{
+					{"a", []byte("1")},
+					{"b", []byte("2")},
+					{"c", nil},
+					{"missing", nil},
+				}
+				for _, tt := range tests {
+					if got := b.Get([]byte(tt.key)); !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("Get(%q) = %q, want %q", tt.key, got, tt.want)
+					}
+				}
+				if got := b.Len(); got != 3 {
+					t.Errorf("Len() = %d, want 3", got)
+				}
+				want := []pair{{"a", "1"}, {"ab", "3"}, {"b", "2"}}
+				if got := collect(t, b.ForEach); !reflect.DeepEqual(got, want) {
+					t.Errorf("ForEach = %v, want %v", got, want)
+				}
+				prefixed := func(fn func(k, v []byte) error) error { return b.ForEachPrefix([]byte("a"), fn) }
+				if got := collect(t, prefixed); !reflect.DeepEqual(got, want[:2]) {
+					t.Errorf("ForEachPrefix = %v, want %v", got, want[:2])
+				}
+				if tx.Bucket("prod") != nil {
+					t.Error("Bucket(prod) is not nil")
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestStoreBucketLifecycle(t *testing.T) {
+	for backend, s := range openTestStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			err := s.Update(func(tx Tx) error {
+				for _, name := range []string{"prod", "dev"} {
+					if _, err := tx.CreateBucket(name); err != nil {
+						return err
+					}
+				}
+				if _, err := tx.CreateBucket("dev"); err == nil {
+					t.Error("creating dev twice did not fail")
+				}
+				if _, err := tx.CreateBucketIfNotExists("dev"); err != nil {
+					t.Errorf("CreateBucketIfNotExists(dev): %v", err)
+				}
+				names := []string{}
+				if err := tx.ForEach(func(name string, _ Bucket) error {
+					names = append(names, name)
+					return nil
+				}); err != nil {
+					return err
+				}
+				if want := []string{"dev", "prod"}; !reflect.DeepEqual(names, want) {
+					t.Errorf("ForEach = %v, want %v", names, want)
+				}
+				return tx.DeleteBucket("prod")
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.View(func(tx Tx) error {
+				if tx.Bucket("prod") != nil {
+					t.Error("prod was not deleted")
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestStoreRollback(t *testing.T) {
+	failed := errors.New("failed")
+	for backend, s := range openTestStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			err := s.Update(func(tx Tx) error {
+				b, err := tx.CreateBucket("dev")
+				if err != nil {
+					return err
+				}
+				if err := b.Put([]byte("a"), []byte("1")); err != nil {
+					return err
+				}
+				return failed
+			})
+			if !errors.Is(err, failed) {
+				t.Fatalf("Update returned %v, want %v", err, failed)
+			}
+			s.View(func(tx Tx) error {
+				if tx.Bucket("dev") != nil {
+					t.Error("bucket of a failed update was kept")
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestStoreReadOnly(t *testing.T) {
+	for backend, s := range openTestStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			err := s.View(func(tx Tx) error {
+				_, err := tx.CreateBucket("dev")
+				return err
+			})
+			if err == nil {
+				t.Error("creating a bucket in a read-only transaction did not fail")
+			}
+		})
+	}
+}
+
+func TestValidateBackend(t *testing.T) {
+	tests := []struct {
+		backend string
+		ok      bool
+	}{
+		{"", true},
+		{BackendBolt, true},
+		{BackendSQLite, sqliteAvailable},
+		{BackendMemory, false},
+		{"etcd", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.backend), func(t *testing.T) {
+			if err := ValidateBackend(tt.backend); (err == nil) != tt.ok {
+				t.Errorf("ValidateBackend(%q) = %v, want ok %v", tt.backend, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMoveStore(t *testing.T) {
+	dir := t.TempDir()
+	path, dst := filepath.Join(dir, "app"), filepath.Join(dir, "renamed")
+	s, err := OpenStore(BackendBolt, path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(func(tx Tx) error {
+		_, err := tx.CreateBucket("dev")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The store cannot move while another writer holds it.
+	err = MoveStore(BackendBolt, path, dst, Options{})
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.PID != os.Getpid() {
+		t.Fatalf("MoveStore returned %v, want a LockedError held by this process", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("the held store was moved: %v", err)
+	}
+	s.Close()
+
+	if err := MoveStore(BackendBolt, path, dst, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, gone := range []string{path, path + lockSuffix} {
+		if _, err := os.Stat(gone); !os.IsNotExist(err) {
+			t.Errorf("%s is still there", gone)
+		}
+	}
+	s, err = OpenStore(BackendBolt, dst, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.View(func(tx Tx) error {
+		if tx.Bucket("dev") == nil {
+			t.Error("bucket dev did not move with the store")
+		}
+		return nil
+	})
+
+	// A workspace that never stored anything has nothing to move.
+	if err := MoveStore(BackendBolt, filepath.Join(dir, "missing"), dst+"2", Options{}); err != nil {
+		t.Errorf("MoveStore of a missing store returned %v", err)
+	}
+}
+
+func TestBoltLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app")
+	s, err := OpenStore(BackendBolt, path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// bbolt retries the lock every 50ms and gives up once the next retry
+	// would pass the timeout.
+	start := time.Now()
+	_, err = OpenStore(BackendBolt, path, Options{LockTimeout: 200 * time.Millisecond})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("opening a held store returned %v, want %v", err, ErrLocked)
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("OpenStore gave up after %v, before the lock timeout", waited)
+	}
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.PID != os.Getpid() {
+		t.Errorf("OpenStore returned %v, want the PID of this process", err)
+	}
+
+	// Readers wait for the writer as well.
+	if _, err := OpenStore(BackendBolt, path, Options{ReadOnly: true}); !errors.Is(err, ErrLocked) {
+		t.Errorf("opening a held store for reading returned %v, want %v", err, ErrLocked)
+	}
+
+	// A zero timeout fails straight away instead of waiting forever.
+	if _, err := OpenStore(BackendBolt, path, Options{}); !errors.Is(err, ErrLocked) {
+		t.Errorf("opening a held store without a timeout returned %v, want %v", err, ErrLocked)
+	}
+}
+
+func TestLockedError(t *testing.T) {
+	tests := []struct {
+		err  *LockedError
+		want string
+	}{
+		{&LockedError{Path: "/tmp/app"}, "workspace /tmp/app is locked by another process"},
+		{&LockedError{Path: "/tmp/app", Workspace: "app"}, "workspace app is locked by another process"},
+		{&LockedError{Path: "/tmp/app", Workspace: "app", PID: 42}, "workspace app is locked by PID 42"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+		if !errors.Is(tt.err, ErrLocked) {
+			t.Errorf("%v does not match ErrLocked", tt.err)
+		}
+		if !errors.Is(fmt.Errorf("opening: %w", tt.err), ErrLocked) {
+			t.Errorf("wrapped %v does not match ErrLocked", tt.err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/Brian-Kariu/ryuk/internal/schema"
+)
+
+// Field is a variable turned into a struct field.
+type Field struct {
+	Name     string
+	Key      string
+	Type     string
+	Default  *string
+	Required bool
+}
+
+// GoType returns the Go type used for the field.
+func (f Field) GoType() string {
+	switch f.Type {
+	case schema.TypeInt:
+		return "int"
+	case schema.TypeBool:
+		return "bool"
+	case schema.TypeURL:
+		return "*url.URL"
+	case schema.TypeDuration:
+		return "time.Duration"
+	case schema.TypeJSON:
+		return "json.RawMessage"
+	}
+	return "string"
+}
+
+// Parse returns the statements that convert value into the field.
+func (f Field) Parse() string {
+	convert := ""
+	switch f.Type {
+	case schema.TypeInt:
+		convert = "strconv.Atoi(value)"
+	case schema.TypeBool:
+		convert = "strconv.ParseBool(value)"
+	case schema.TypeURL:
+		convert = "url.Parse(value)"
+	case schema.TypeDuration:
+		convert = "time.ParseDuration(value)"
+	case schema.TypeJSON:
+		return fmt.Sprintf(`if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("%%s: invalid json", %q)
+		}
+		c.%s = json.RawMessage(value)`, f.Key, f.Name)
+	default:
+		return fmt.Sprintf("c.%s = value", f.Name)
+	}
+	return fmt.Sprintf(`v, err := %s
+		if err != nil {
+			return nil, fmt.Errorf("%%s: %%w", %q, err)
+		}
+		c.%s = v`, convert, f.Key, f.Name)
+}
+
+// Fields builds the fields for keys, taking types, defaults and required
+// keys from s when it is set. Keys only found in s are included too.
+func Fields(keys []string, s *schema.Schema) ([]Field, error) {
+	all := map[string]bool{}
+	for _, key := range keys {
+		all[key] = true
+	}
+	if s != nil {
+		for key := range s.Keys {
+			all[key] = true
+		}
+	}
+
+	fields := []Field{}
+	names := map[string]string{}
+	for key := range all {
+		field := Field{Name: FieldName(key), Key: key, Type: schema.TypeString}
+		if other, ok := names[field.Name]; ok {
+			return nil, fmt.Errorf("keys %s and %s both map to field %s", other, key, field.Name)
+		}
+		names[field.Name] = key
+		if s != nil {
+			if k, ok := s.Keys[key]; ok {
+				field.Type = k.Type
+				field.Default = k.Default
+				field.Required = k.Required.All
+			}
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+	return fields, nil
+}
+
+// initialisms are written in upper case in field names, as golint expects.
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "DB": true, "DNS": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true,
+	"URL": true, "UUID": true, "XML": true,
+}
+
+// FieldName turns a variable name such as DATABASE_URL into an exported Go
+// identifier such as DatabaseURL.
+func FieldName(key string) string {
+	parts := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		upper := strings.ToUpper(part)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		lower := []rune(strings.ToLower(part))
+		lower[0] = unicode.ToUpper(lower[0])
+		b.WriteString(string(lower))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+	"deref": func(s *string) string { return *s },
+}).Parse(`// Code generated by ryuk codegen; DO NOT EDIT.
+
+package {{.Package}}
+{{if .Imports}}
+import (
+{{- range .Imports}}
+	{{quote .}}
+{{- end}}
+)
+{{end}}
+// {{.Type}} holds the variables of the {{.Source}}.
+type {{.Type}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.GoType}} ` + "`" + `env:{{quote .Key}}` + "`" + `
+{{- end}}
+}
+
+// Load reads {{.Type}} from the process environment.
+func Load() (*{{.Type}}, error) {
+	return LoadFrom(os.LookupEnv)
+}
+
+// LoadFrom reads {{.Type}} using lookup, which returns the value of a key
+// and whether it is set.
+func LoadFrom(lookup func(string) (string, bool)) (*{{.Type}}, error) {
+	c := &{{.Type}}{}
+{{- if .Fields}}
+	var (
+		value string
+		ok    bool
+	)
+{{- end}}
+{{- range .Fields}}
+
+	value, ok = lookup({{quote .Key}})
+{{- if .Default}}
+	if !ok {
+		value, ok = {{quote (deref .Default)}}, true
+	}
+{{- end}}
+{{- if .Required}}
+	if !ok {
+		return nil, fmt.Errorf("%s is not set", {{quote .Key}})
+	}
+{{- end}}
+	if ok {
+		{{.Parse}}
+	}
+{{- end}}
+	return c, nil
+}
+`))
+
+// Go writes a Go source file declaring typeName with one field per entry of
+// fields, along with Load and LoadFrom functions.
+func Go(w io.Writer, pkg, typeName, source string, fields []Field) error {
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("invalid package name %q", pkg)
+	}
+	if !token.IsIdentifier(typeName) || !token.IsExported(typeName) {
+		return fmt.Errorf("invalid type name %q, it must be an exported identifier", typeName)
+	}
+
+	imports := map[string]bool{"os": true}
+	for _, field := range fields {
+		if field.Required || field.Type != schema.TypeString {
+			imports["fmt"] = true
+		}
+		switch field.Type {
+		case schema.TypeInt, schema.TypeBool:
+			imports["strconv"] = true
+		case schema.TypeURL:
+			imports["net/url"] = true
+		case schema.TypeDuration:
+			imports["time"] = true
+		case schema.TypeJSON:
+			imports["encoding/json"] = true
+		}
+	}
+	sorted := []string{}
+	for path := range imports {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var buf bytes.Buffer
+	err := goTemplate.Execute(&buf, map[string]any{
+		"Package": pkg,
+		"Type":    typeName,
+		"Source":  source,
+		"Imports": sorted,
+		"Fields":  fields,
+	})
+	if err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("Error formatting generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
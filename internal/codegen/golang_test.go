@@ -0,0 +1,134 @@
+package codegen
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brian-Kariu/ryuk/internal/schema"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const testSchema = `keys:
+  DATABASE_URL:
+    type: url
+    required: true
+  PORT:
+    type: int
+    default: "8080"
+  DEBUG:
+    type: bool
+  TIMEOUT:
+    type: duration
+    default: 5s
+  FEATURES:
+    type: json
+  API_TOKEN:
+    required: [prod]
+`
+
+func TestGoGolden(t *testing.T) {
+	s, err := schema.Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := Fields([]string{"HOST", "PORT"}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Go(&out, "config", "Config", "dev environment", fields); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "config.go.golden")
+	if *update {
+		if err := os.WriteFile(golden, out.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("generated code does not match %s, rerun with -update if the change is expected:\n%s", golden, out.Bytes())
+	}
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v", err)
+	}
+	if !bytes.Equal(formatted, out.Bytes()) {
+		t.Error("generated code is not gofmt formatted")
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "config.go", out.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("config", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("generated code does not compile: %v", err)
+	}
+}
+
+func TestGoNoFields(t *testing.T) {
+	var out bytes.Buffer
+	if err := Go(&out, "env", "Env", "dev environment", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := format.Source(out.Bytes()); err != nil {
+		t.Errorf("generated code does not parse: %v\n%s", err, out.Bytes())
+	}
+}
+
+func TestGoInvalidNames(t *testing.T) {
+	tests := []struct {
+		pkg, typeName string
+	}{
+		{"my-config", "Config"},
+		{"config", "config"},
+		{"config", "My Config"},
+		{"func", "Config"},
+	}
+	for _, tt := range tests {
+		if err := Go(&bytes.Buffer{}, tt.pkg, tt.typeName, "dev", nil); err == nil {
+			t.Errorf("Go(%q, %q) did not fail", tt.pkg, tt.typeName)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"DATABASE_URL", "DatabaseURL"},
+		{"api_token", "APIToken"},
+		{"PORT", "Port"},
+		{"log.level", "LogLevel"},
+		{"2FA_SECRET", "X2faSecret"},
+		{"__", "X"},
+	}
+	for _, tt := range tests {
+		if got := FieldName(tt.key); got != tt.want {
+			t.Errorf("FieldName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestFieldsCollision(t *testing.T) {
+	if _, err := Fields([]string{"API_TOKEN", "api.token"}, nil); err == nil {
+		t.Error("Fields did not reject two keys with the same field name")
+	}
+}
@@ -0,0 +1,50 @@
+package diff
+
+import "sort"
+
+// Change is a key whose value differs between two environments.
+type Change struct {
+	Key string `json:"key" yaml:"key"`
+	A   string `json:"a" yaml:"a"`
+	B   string `json:"b" yaml:"b"`
+}
+
+// Result lists the differences between environment A and B. All slices are
+// sorted by key.
+type Result struct {
+	OnlyInA []string `json:"only_in_a" yaml:"only_in_a"`
+	OnlyInB []string `json:"only_in_b" yaml:"only_in_b"`
+	Changed []Change `json:"changed" yaml:"changed"`
+}
+
+func (r Result) Empty() bool {
+	return len(r.OnlyInA) == 0 && len(r.OnlyInB) == 0 && len(r.Changed) == 0
+}
+
+// Compare returns the keys that are missing from either side or hold
+// different values.
+func Compare(a, b map[string]string) Result {
+	result := Result{OnlyInA: []string{}, OnlyInB: []string{}, Changed: []Change{}}
+	for k, va := range a {
+		vb, ok := b[k]
+		if !ok {
+			result.OnlyInA = append(result.OnlyInA, k)
+			continue
+		}
+		if va != vb {
+			result.Changed = append(result.Changed, Change{Key: k, A: va, B: vb})
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			result.OnlyInB = append(result.OnlyInB, k)
+		}
+	}
+
+	sort.Strings(result.OnlyInA)
+	sort.Strings(result.OnlyInB)
+	sort.Slice(result.Changed, func(i, j int) bool {
+		return result.Changed[i].Key < result.Changed[j].Key
+	})
+	return result
+}
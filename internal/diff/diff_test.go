@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]string
+		want Result
+	}{
+		{
+			name: "equal",
+			a:    map[string]string{"HOST": "localhost"},
+			b:    map[string]string{"HOST": "localhost"},
+			want: Result{OnlyInA: []string{}, OnlyInB: []string{}, Changed: []Change{}},
+		},
+		{
+			name: "empty",
+			a:    map[string]string{},
+			b:    nil,
+			want: Result{OnlyInA: []string{}, OnlyInB: []string{}, Changed: []Change{}},
+		},
+		{
+			name: "removed",
+			a:    map[string]string{"PORT": "80", "HOST": "localhost", "DEBUG": "1"},
+			b:    map[string]string{"HOST": "localhost"},
+			want: Result{OnlyInA: []string{"DEBUG", "PORT"}, OnlyInB: []string{}, Changed: []Change{}},
+		},
+		{
+			name: "added",
+			a:    map[string]string{"HOST": "localhost"},
+			b:    map[string]string{"HOST": "localhost", "TLS": "on", "CA": "x"},
+			want: Result{OnlyInA: []string{}, OnlyInB: []string{"CA", "TLS"}, Changed: []Change{}},
+		},
+		{
+			name: "changed",
+			a:    map[string]string{"HOST": "localhost", "PORT": "80", "EMPTY": ""},
+			b:    map[string]string{"HOST": "db.internal", "PORT": "80", "EMPTY": "set"},
+			want: Result{OnlyInA: []string{}, OnlyInB: []string{}, Changed: []Change{
+				{Key: "EMPTY", A: "", B: "set"},
+				{Key: "HOST", A: "localhost", B: "db.internal"},
+			}},
+		},
+		{
+			name: "mixed",
+			a:    map[string]string{"OLD": "1", "HOST": "a"},
+			b:    map[string]string{"NEW": "2", "HOST": "b"},
+			want: Result{OnlyInA: []string{"OLD"}, OnlyInB: []string{"NEW"}, Changed: []Change{{Key: "HOST", A: "a", B: "b"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare = %+v, want %+v", got, tt.want)
+			}
+			if empty := len(tt.want.OnlyInA)+len(tt.want.OnlyInB)+len(tt.want.Changed) == 0; got.Empty() != empty {
+				t.Errorf("Empty() = %v, want %v", got.Empty(), empty)
+			}
+		})
+	}
+}
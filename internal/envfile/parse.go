@@ -0,0 +1,228 @@
+package envfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pair is a single KEY=VALUE entry read from a dotenv file.
+type Pair struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// Parse reads dotenv formatted data. It supports comments, an optional
+// export prefix, single and double quoted values that may span several
+// lines, and the usual backslash escapes inside double quotes. Pairs are
+// returned in the order they appear; when a key repeats, the last value wins.
+func Parse(data string) ([]Pair, error) {
+	p := &parser{src: strings.ReplaceAll(data, "\r\n", "\n"), line: 1}
+	pairs := []Pair{}
+	index := map[string]int{}
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return pairs, nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		pair, err := p.parsePair()
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[pair.Key]; ok {
+			pairs[i] = pair
+			continue
+		}
+		index[pair.Key] = len(pairs)
+		pairs = append(pairs, pair)
+	}
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() byte { return p.src[p.pos] }
+
+func (p *parser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipBlank skips whitespace including newlines.
+func (p *parser) skipBlank() {
+	for !p.eof() && strings.IndexByte(" \t\n", p.peek()) >= 0 {
+		p.next()
+	}
+}
+
+// skipSpace skips whitespace on the current line.
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func isKeyChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func (p *parser) parseKey() string {
+	start := p.pos
+	for !p.eof() && isKeyChar(p.peek()) {
+		p.next()
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) parsePair() (Pair, error) {
+	line := p.line
+	key := p.parseKey()
+	if key == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpace()
+		key = p.parseKey()
+	}
+	if key == "" {
+		return Pair{}, p.errorf("expected a variable name")
+	}
+
+	p.skipSpace()
+	if p.eof() || p.peek() != '=' {
+		return Pair{}, p.errorf("expected '=' after %s", key)
+	}
+	p.next()
+	p.skipSpace()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return Pair{}, err
+	}
+	return Pair{Key: key, Value: value, Line: line}, nil
+}
+
+func (p *parser) parseValue() (string, error) {
+	if p.eof() || p.peek() == '\n' {
+		return "", nil
+	}
+
+	var value string
+	var err error
+	switch p.peek() {
+	case '\'':
+		value, err = p.parseSingleQuoted()
+	case '"':
+		value, err = p.parseDoubleQuoted()
+	default:
+		return p.parseUnquoted(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Only whitespace or a comment may follow a closing quote.
+	p.skipSpace()
+	if p.eof() {
+		return value, nil
+	}
+	switch p.peek() {
+	case '\n':
+		p.next()
+	case '#':
+		p.skipLine()
+	default:
+		return "", p.errorf("unexpected character %q after quoted value", p.peek())
+	}
+	return value, nil
+}
+
+func (p *parser) parseUnquoted() string {
+	start := p.pos
+	end := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		c := p.next()
+		if c == '#' && (p.pos-1 == start || p.src[p.pos-2] == ' ' || p.src[p.pos-2] == '\t') {
+			p.skipLine()
+			return strings.TrimRight(p.src[start:end], " \t")
+		}
+		end = p.pos
+	}
+	if !p.eof() {
+		p.next()
+	}
+	return strings.TrimRight(p.src[start:end], " \t")
+}
+
+func (p *parser) parseSingleQuoted() (string, error) {
+	line := p.line
+	p.next()
+	start := p.pos
+	for !p.eof() {
+		if p.peek() == '\'' {
+			value := p.src[start:p.pos]
+			p.next()
+			return value, nil
+		}
+		p.next()
+	}
+	return "", fmt.Errorf("line %d: unterminated single quoted value", line)
+}
+
+func (p *parser) parseDoubleQuoted() (string, error) {
+	line := p.line
+	p.next()
+	var b strings.Builder
+	for !p.eof() {
+		c := p.next()
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.eof() {
+				break
+			}
+			escaped := p.next()
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$', '`', '\'':
+				b.WriteByte(escaped)
+			case '\n':
+				// Line continuation.
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(escaped)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated double quoted value", line)
+}
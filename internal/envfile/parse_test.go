@@ -0,0 +1,103 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Pair
+	}{
+		{
+			name: "empty",
+			data: "",
+			want: []Pair{},
+		},
+		{
+			name: "unquoted",
+			data: "A=1\nB = two words  \n",
+			want: []Pair{{"A", "1", 1}, {"B", "two words", 2}},
+		},
+		{
+			name: "comments and blank lines",
+			data: "# header\n\nA=1 # trailing\nB=a#b\n",
+			want: []Pair{{"A", "1", 3}, {"B", "a#b", 4}},
+		},
+		{
+			name: "export prefix",
+			data: "export A=1\nexport=2\n",
+			want: []Pair{{"A", "1", 1}, {"export", "2", 2}},
+		},
+		{
+			name: "empty value",
+			data: "A=\nB=\"\"\n",
+			want: []Pair{{"A", "", 1}, {"B", "", 2}},
+		},
+		{
+			name: "single quoted",
+			data: `A='no $escapes\n here' # comment`,
+			want: []Pair{{"A", `no $escapes\n here`, 1}},
+		},
+		{
+			name: "double quoted escapes",
+			data: `A="tab\there\n\"quoted\" \$HOME \x"`,
+			want: []Pair{{"A", "tab\there\n\"quoted\" $HOME \\x", 1}},
+		},
+		{
+			name: "multiline",
+			data: "A=\"one\ntwo\"\nB='three\nfour'\nC=5\n",
+			want: []Pair{{"A", "one\ntwo", 1}, {"B", "three\nfour", 3}, {"C", "5", 5}},
+		},
+		{
+			name: "line continuation",
+			data: "A=\"one \\\ntwo\"\n",
+			want: []Pair{{"A", "one two", 1}},
+		},
+		{
+			name: "crlf",
+			data: "A=1\r\nB=2\r\n",
+			want: []Pair{{"A", "1", 1}, {"B", "2", 2}},
+		},
+		{
+			name: "last value wins",
+			data: "A=1\nB=2\nA=3\n",
+			want: []Pair{{"A", "3", 3}, {"B", "2", 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.data)
+			if err != nil {
+				t.Fatalf("Parse returned %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"missing key", "=1", "line 1: expected a variable name"},
+		{"missing equals", "A=1\nB 2", "line 2: expected '=' after B"},
+		{"unterminated double quote", "A=1\nB=\"open\n", "line 2: unterminated double quoted value"},
+		{"unterminated single quote", "A='open", "line 1: unterminated single quoted value"},
+		{"text after quote", `A="1" 2`, `line 1: unexpected character '2' after quoted value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse returned %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package envfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats lists the output formats understood by Write.
+var Formats = []string{"dotenv", "json", "yaml", "toml", "shell", "docker-env-file"}
+
+var (
+	shellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	plainText = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+)
+
+// Write renders vars in the given format. Keys are always written in sorted
+// order so the output is stable between runs.
+func Write(w io.Writer, format string, vars map[string]string) error {
+	switch format {
+	case "dotenv":
+		return writeLines(w, vars, func(k, v string) (string, error) {
+			if !isDotenvKey(k) {
+				return "", fmt.Errorf("%s is not a valid dotenv variable name", k)
+			}
+			return k + "=" + QuoteDotenv(v), nil
+		})
+	case "shell":
+		return writeLines(w, vars, func(k, v string) (string, error) {
+			if !shellName.MatchString(k) {
+				return "", fmt.Errorf("%s is not a valid shell variable name", k)
+			}
+			return "export " + k + "=" + quoteShell(v), nil
+		})
+	case "docker-env-file":
+		return writeLines(w, vars, func(k, v string) (string, error) {
+			if !isDotenvKey(k) {
+				return "", fmt.Errorf("%s is not a valid docker env file variable name", k)
+			}
+			if strings.ContainsAny(v, "\r\n") {
+				return "", fmt.Errorf("%s contains a newline which docker env files do not support", k)
+			}
+			return k + "=" + v, nil
+		})
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(vars)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(vars); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "toml":
+		return toml.NewEncoder(w).Encode(vars)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func writeLines(w io.Writer, vars map[string]string, line func(k, v string) (string, error)) error {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		l, err := line(k, vars[k])
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isDotenvKey reports whether Parse reads k back as a variable name.
+func isDotenvKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for i := 0; i < len(k); i++ {
+		if !isKeyChar(k[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// QuoteDotenv returns v as it should appear after the = in a dotenv file,
+// adding double quotes and escapes only when they are needed.
+func QuoteDotenv(v string) string {
+	if plainText.MatchString(v) {
+		return v
+	}
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"`", "\\`",
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	)
+	return `"` + replacer.Replace(v) + `"`
+}
+
+func quoteShell(v string) string {
+	if v != "" && plainText.MatchString(v) {
+		return v
+	}
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
@@ -0,0 +1,150 @@
+package envfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestQuoteDotenv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"postgres://user@db:5432/app?x=1", `"postgres://user@db:5432/app?x=1"`},
+		{"a,b=c+d", "a,b=c+d"},
+		{"two words", `"two words"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"$HOME and `cmd`", "\"\\$HOME and \\`cmd\\`\""},
+		{"one\ntwo\tthree\r", `"one\ntwo\tthree\r"`},
+		{`back\slash`, `"back\\slash"`},
+		{"# not a comment", `"# not a comment"`},
+		{"it's", `"it's"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := QuoteDotenv(tt.value)
+			if got != tt.want {
+				t.Errorf("QuoteDotenv(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+			// Whatever is written reads back as the same value.
+			pairs, err := Parse("KEY=" + got)
+			if err != nil {
+				t.Fatalf("Parse(%s) returned %v", got, err)
+			}
+			if len(pairs) != 1 || pairs[0].Value != tt.value {
+				t.Errorf("Parse(%s) = %q, want %q", got, pairs, tt.value)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	vars := map[string]string{
+		"PORT":    "8080",
+		"NAME":    "my app",
+		"MESSAGE": "it's \"quoted\"",
+		"EMPTY":   "",
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"dotenv", `EMPTY=
+MESSAGE="it's \"quoted\""
+NAME="my app"
+PORT=8080
+`},
+		{"shell", `export EMPTY=''
+export MESSAGE='it'\''s "quoted"'
+export NAME='my app'
+export PORT=8080
+`},
+		{"docker-env-file", `EMPTY=
+MESSAGE=it's "quoted"
+NAME=my app
+PORT=8080
+`},
+		{"json", `{
+  "EMPTY": "",
+  "MESSAGE": "it's \"quoted\"",
+  "NAME": "my app",
+  "PORT": "8080"
+}
+`},
+		{"yaml", `EMPTY: ""
+MESSAGE: it's "quoted"
+NAME: my app
+PORT: "8080"
+`},
+		{"toml", `EMPTY = ''
+MESSAGE = "it's \"quoted\""
+NAME = 'my app'
+PORT = '8080'
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, vars); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Write(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "dotenv", vars); err != nil {
+		t.Fatal(err)
+	}
+	pairs, err := Parse(buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range pairs {
+		if vars[pair.Key] != pair.Value {
+			t.Errorf("%s reads back as %q, want %q", pair.Key, pair.Value, vars[pair.Key])
+		}
+	}
+}
+
+func TestWriteInvalidKeys(t *testing.T) {
+	tests := []struct {
+		format string
+		key    string
+		value  string
+		want   string
+	}{
+		{"dotenv", "MY KEY", "v", "MY KEY is not a valid dotenv variable name"},
+		{"dotenv", "A=B", "v", "A=B is not a valid dotenv variable name"},
+		{"dotenv", "", "v", " is not a valid dotenv variable name"},
+		{"dotenv", "LINE\nBREAK", "v", "is not a valid dotenv variable name"},
+		{"docker-env-file", "MY KEY", "v", "MY KEY is not a valid docker env file variable name"},
+		{"docker-env-file", "KEY", "a\nb", "KEY contains a newline"},
+		{"shell", "log.level", "v", "log.level is not a valid shell variable name"},
+		{"shell", "1ST", "v", "1ST is not a valid shell variable name"},
+		{"xml", "KEY", "v", `unknown format "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.key, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, tt.format, map[string]string{tt.key: tt.value})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Write returned %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Names the parser accepts are written as they are.
+	var buf bytes.Buffer
+	if err := Write(&buf, "dotenv", map[string]string{"log.level": "debug", "my-key": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "log.level=debug\nmy-key=1\n"; got != want {
+		t.Errorf("Write = %q, want %q", got, want)
+	}
+}
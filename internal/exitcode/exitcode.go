@@ -0,0 +1,51 @@
+package exitcode
+
+import (
+	"errors"
+	"os"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/Brian-Kariu/ryuk/db"
+)
+
+// Exit codes used by ryuk commands. Scripts can rely on these to tell a
+// missing workspace, environment or key apart from other failures.
+const (
+	OK                = 0
+	Error             = 1
+	Differences       = 2 // env diff --exit-code found differences
+	WorkspaceNotFound = 3
+	EnvNotFound       = 4
+	KeyNotFound       = 5
+	Locked            = 6
+)
+
+// Code returns the exit code matching err.
+func Code(err error) int {
+	switch {
+	case err == nil:
+		return OK
+	case errors.Is(err, db.ErrWorkspaceNotFound):
+		return WorkspaceNotFound
+	case errors.Is(err, db.ErrEnvNotFound):
+		return EnvNotFound
+	case errors.Is(err, db.ErrKeyNotFound):
+		return KeyNotFound
+	case errors.Is(err, db.ErrLocked):
+		return Locked
+	}
+	return Error
+}
+
+// Fatal logs msg along with err and exits with the code matching err.
+func Fatal(msg string, err error, keyvals ...interface{}) {
+	log.Error(msg, append(keyvals, "err", err)...)
+	os.Exit(Code(err))
+}
+
+// Exit logs err and exits with the code matching it.
+func Exit(err error) {
+	log.Error(err)
+	os.Exit(Code(err))
+}
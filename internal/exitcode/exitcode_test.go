@@ -0,0 +1,39 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, OK},
+		{"other error", errors.New("boom"), Error},
+		{"workspace not found", db.ErrWorkspaceNotFound, WorkspaceNotFound},
+		{"workspace not found from config", config.ErrWorkspaceNotFound, WorkspaceNotFound},
+		{"env not found", db.ErrEnvNotFound, EnvNotFound},
+		{"env not found from config", config.ErrEnvNotFound, EnvNotFound},
+		{"key not found", db.ErrKeyNotFound, KeyNotFound},
+		{"locked", db.ErrLocked, Locked},
+		{"locked error", &db.LockedError{Path: "ws", PID: 42}, Locked},
+		{"wrapped", fmt.Errorf("reading prod: %w", db.ErrKeyNotFound), KeyNotFound},
+		{"wrapped twice", fmt.Errorf("a: %w", fmt.Errorf("b: %w", config.ErrEnvNotFound)), EnvNotFound},
+		{"wrapped locked error", fmt.Errorf("opening: %w", &db.LockedError{Path: "ws"}), Locked},
+		{"formatted without wrapping", fmt.Errorf("reading prod: %v", db.ErrKeyNotFound), Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.err); got != tt.want {
+				t.Errorf("Code(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+	Plain = "plain"
+)
+
+// Formats lists the values accepted by the --output flag.
+var Formats = []string{Table, JSON, YAML, Plain}
+
+// Validate returns an error when format is not one of Formats.
+func Validate(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// IsTerminal reports whether stdout is attached to a terminal.
+func IsTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// Render writes data to stdout in the requested format. JSON and YAML encode
+// data directly, plain prints rows as tab separated lines. Table runs the
+// interactive view when stdout is a terminal and falls back to plain text
+// otherwise, so piping a command never starts a TUI.
+func Render(format string, data any, rows [][]string, table func() error) error {
+	return render(os.Stdout, format, data, rows, table)
+}
+
+func render(w io.Writer, format string, data any, rows [][]string, table func() error) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case YAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case Table:
+		if table != nil && IsTerminal() {
+			return table()
+		}
+		fallthrough
+	case Plain:
+		for _, row := range rows {
+			if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return Validate(format)
+}
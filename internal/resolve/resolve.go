@@ -0,0 +1,354 @@
+package resolve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/schema"
+	"github.com/Brian-Kariu/ryuk/internal/secret"
+)
+
+// Resolver loads the variables of environments and expands ${...}
+// references between them. References can point to a variable in the same
+// environment (${KEY}), another environment of the same workspace
+// (${env:KEY}) or another workspace (${workspace/env:KEY}). Write $${ for a
+// literal ${.
+//
+// A value that references a secret is treated as a secret itself.
+type Resolver struct {
+	file     *config.File
+	loaded   map[string]map[string]db.ResolvedVar
+	schemas  map[string]*schema.Schema
+	expanded map[string]string
+	secret   map[string]bool
+	stack    []string
+}
+
+// New returns a Resolver reading the workspaces of the global config.
+func New() *Resolver {
+	return NewFrom(config.GlobalFile())
+}
+
+// NewFrom returns a Resolver reading the workspaces of f.
+func NewFrom(f *config.File) *Resolver {
+	return &Resolver{
+		file:     f,
+		loaded:   map[string]map[string]db.ResolvedVar{},
+		schemas:  map[string]*schema.Schema{},
+		expanded: map[string]string{},
+		secret:   map[string]bool{},
+	}
+}
+
+// Load returns the effective variables of env in workspace, with inherited
+// values included. References are expanded unless raw is set.
+func Load(workspace, env string, raw bool) (map[string]db.ResolvedVar, error) {
+	return New().Load(workspace, env, raw)
+}
+
+// Load is the package level Load for the workspaces of r.
+func (r *Resolver) Load(workspace, env string, raw bool) (map[string]db.ResolvedVar, error) {
+	if raw {
+		return r.raw(workspace, env)
+	}
+	return r.Expand(workspace, env)
+}
+
+// LoadEach is Load with references always expanded, for listings. A
+// variable whose references can not be expanded keeps its raw value and its
+// error is returned in failed instead of failing the whole environment.
+func LoadEach(workspace, env string) (vars map[string]db.ResolvedVar, failed map[string]error, err error) {
+	return New().ExpandEach(workspace, env)
+}
+
+// Lookup returns the effective value of a single key.
+func Lookup(workspace, env, key string, raw bool) (string, error) {
+	v, err := LookupVar(workspace, env, key, raw)
+	return v.Value, err
+}
+
+// LookupVar is Lookup with the layer and classification of the key.
+func LookupVar(workspace, env, key string, raw bool) (db.ResolvedVar, error) {
+	return New().LookupVar(workspace, env, key, raw)
+}
+
+// LookupVar is the package level LookupVar for the workspaces of r.
+func (r *Resolver) LookupVar(workspace, env, key string, raw bool) (db.ResolvedVar, error) {
+	vars, err := r.raw(workspace, env)
+	if err != nil {
+		return db.ResolvedVar{}, err
+	}
+	v, ok := vars[key]
+	if !ok {
+		return db.ResolvedVar{}, fmt.Errorf("%w: %s in environment %s", db.ErrKeyNotFound, key, env)
+	}
+	if raw {
+		return v, nil
+	}
+	value, err := r.expand(workspace, env, key)
+	if err != nil {
+		return db.ResolvedVar{}, err
+	}
+	v.Value = value
+	v.Secret = r.secret[varID(workspace, env, key)]
+	return v, nil
+}
+
+// ExpandValue expands value as if it was stored under key in env, so that a
+// new value can be checked before it is written.
+func ExpandValue(workspace, env, key, value string) (string, error) {
+	return New().ExpandValue(workspace, env, key, value)
+}
+
+// ExpandValue is the package level ExpandValue for the workspaces of r.
+func (r *Resolver) ExpandValue(workspace, env, key, value string) (string, error) {
+	vars, err := r.raw(workspace, env)
+	if err != nil {
+		return "", err
+	}
+	v := vars[key]
+	v.Value = value
+	v.Layer = env
+	vars[key] = v
+	return r.expand(workspace, env, key)
+}
+
+// IsSecret reports whether key of env is a secret. Keys that are not set,
+// such as deleted ones, are classified by the schema and their name.
+func IsSecret(workspace, env, key string) (bool, error) {
+	r := New()
+	vars, err := r.raw(workspace, env)
+	if err != nil {
+		return false, err
+	}
+	if v, ok := vars[key]; ok {
+		return v.Secret, nil
+	}
+	ws, err := r.file.GetWorkspace(workspace)
+	if err != nil {
+		return false, err
+	}
+	return classify(key, nil, r.schema(ws)), nil
+}
+
+// schema returns the schema of ws, caching it. A schema that can not be
+// loaded is reported and ignored, so that a broken schema file does not get
+// in the way of reading variables; ryuk validate fails on it instead.
+func (r *Resolver) schema(ws config.WorkspaceConfig) *schema.Schema {
+	if s, ok := r.schemas[ws.Name]; ok {
+		return s
+	}
+	s, err := schema.ForProject(ws.Project)
+	if err != nil {
+		log.Warn("Ignoring the workspace schema", "workspace", ws.Name, "err", err)
+	}
+	r.schemas[ws.Name] = s
+	return s
+}
+
+// raw loads the unexpanded variables of an environment, caching the result.
+func (r *Resolver) raw(workspace, env string) (map[string]db.ResolvedVar, error) {
+	id := workspace + "/" + env
+	if vars, ok := r.loaded[id]; ok {
+		return vars, nil
+	}
+
+	ws, err := r.file.GetWorkspace(workspace)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := ws.EnvChain(env)
+	if err != nil {
+		return nil, err
+	}
+	client, err := db.NewClientFrom(r.file, ws, true)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	vars, err := client.ResolveVars(chain)
+	if err != nil {
+		return nil, err
+	}
+	s := r.schema(ws)
+	for key, v := range vars {
+		v.Secret = classify(key, v.Record.Secret, s)
+		vars[key] = v
+	}
+	r.loaded[id] = vars
+	return vars, nil
+}
+
+// Expand returns every variable of an environment with references expanded.
+// It fails if any variable can not be expanded.
+func (r *Resolver) Expand(workspace, env string) (map[string]db.ResolvedVar, error) {
+	expanded, failed, err := r.ExpandEach(workspace, env)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(failed))
+	for key := range failed {
+		keys = append(keys, key)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return nil, failed[keys[0]]
+	}
+	return expanded, nil
+}
+
+// ExpandEach expands every variable of an environment. Variables that can
+// not be expanded keep their raw value and are reported in failed.
+func (r *Resolver) ExpandEach(workspace, env string) (map[string]db.ResolvedVar, map[string]error, error) {
+	vars, err := r.raw(workspace, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	expanded := make(map[string]db.ResolvedVar, len(vars))
+	failed := map[string]error{}
+	for key, v := range vars {
+		id := varID(workspace, env, key)
+		value, err := r.expand(workspace, env, key)
+		if err != nil {
+			failed[key] = err
+		} else {
+			v.Value = value
+		}
+		v.Secret = v.Secret || r.secret[id]
+		expanded[key] = v
+	}
+	return expanded, failed, nil
+}
+
+// classify decides whether key is a secret, see secret.Classify.
+func classify(key string, flag *bool, s *schema.Schema) bool {
+	var inSchema *bool
+	if s != nil {
+		if k, ok := s.Keys[key]; ok {
+			inSchema = k.Secret
+		}
+	}
+	return secret.Classify(key, flag, inSchema)
+}
+
+func varID(workspace, env, key string) string {
+	return fmt.Sprintf("%s/%s:%s", workspace, env, key)
+}
+
+func (r *Resolver) expand(workspace, env, key string) (string, error) {
+	id := varID(workspace, env, key)
+	if value, ok := r.expanded[id]; ok {
+		return value, nil
+	}
+	for i, ref := range r.stack {
+		if ref == id {
+			cycle := append(append([]string{}, r.stack[i:]...), id)
+			return "", fmt.Errorf("reference cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	vars, err := r.raw(workspace, env)
+	if err != nil {
+		return "", err
+	}
+	v, ok := vars[key]
+	if !ok {
+		return "", fmt.Errorf("undefined variable %s", id)
+	}
+
+	r.secret[id] = r.secret[id] || v.Secret
+	r.stack = append(r.stack, id)
+	value, err := r.interpolate(workspace, env, v.Value)
+	r.stack = r.stack[:len(r.stack)-1]
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", key, err)
+	}
+	r.expanded[id] = value
+	return value, nil
+}
+
+// interpolate replaces the references in value. Relative references are
+// resolved against workspace and env.
+func (r *Resolver) interpolate(workspace, env, value string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		if start > 0 && value[start-1] == '$' {
+			b.WriteString(value[:start-1])
+			b.WriteString("${")
+			value = value[start+2:]
+			continue
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference, write $${ for a literal ${")
+		}
+
+		refWorkspace, refEnv, refKey, err := parseReference(workspace, env, value[start+2:start+end])
+		if err != nil {
+			return "", err
+		}
+		expanded, err := r.expand(refWorkspace, refEnv, refKey)
+		if err != nil {
+			return "", err
+		}
+		if r.secret[varID(refWorkspace, refEnv, refKey)] && len(r.stack) > 0 {
+			r.secret[r.stack[len(r.stack)-1]] = true
+		}
+		b.WriteString(value[:start])
+		b.WriteString(expanded)
+		value = value[start+end+1:]
+	}
+}
+
+// parseReference splits KEY, env:KEY or workspace/env:KEY.
+func parseReference(workspace, env, ref string) (string, string, string, error) {
+	key := ref
+	if scope, k, ok := strings.Cut(ref, ":"); ok {
+		// Shell modifiers such as ${VAR:-default} would otherwise read as
+		// a key of environment VAR.
+		if k != "" && strings.ContainsAny(k[:1], "-=?+") {
+			return "", "", "", fmt.Errorf("unsupported reference ${%s}, defaults and other shell modifiers are not supported; write $${ for a literal ${", ref)
+		}
+		key = k
+		env = scope
+		if ws, e, ok := strings.Cut(scope, "/"); ok {
+			workspace, env = ws, e
+		}
+	}
+	if key == "" || env == "" || workspace == "" {
+		return "", "", "", fmt.Errorf("invalid reference ${%s}, expected KEY, env:KEY or workspace/env:KEY; write $${ for a literal ${", ref)
+	}
+	return workspace, env, key, nil
+}
+
+// Values drops the layer information from vars.
+func Values(vars map[string]db.ResolvedVar) map[string]string {
+	values := make(map[string]string, len(vars))
+	for k, v := range vars {
+		values[k] = v.Value
+	}
+	return values
+}
+
+// ParseEnvRef splits an environment reference of the form env or
+// workspace/env. A bare env belongs to defaultWorkspace.
+func ParseEnvRef(ref, defaultWorkspace string) (string, string, error) {
+	workspace, env := defaultWorkspace, ref
+	if ws, e, ok := strings.Cut(ref, "/"); ok {
+		workspace, env = ws, e
+	}
+	if workspace == "" || env == "" {
+		return "", "", fmt.Errorf("invalid environment %q, expected env or workspace/env", ref)
+	}
+	return workspace, env, nil
+}
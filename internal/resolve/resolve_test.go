@@ -0,0 +1,271 @@
+package resolve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/schema"
+)
+
+// newTestResolver returns a Resolver over in-memory workspaces filled with
+// vars, keyed by workspace then env.
+func newTestResolver(t *testing.T, vars map[string]map[string]map[string]string) *Resolver {
+	t.Helper()
+	key := []byte(strings.Repeat("k", 32))
+	workspaces := []config.WorkspaceConfig{}
+	for name, envs := range vars {
+		ws := config.WorkspaceConfig{
+			ID:          name,
+			Name:        name,
+			DB:          fmt.Sprintf("%s/%s", t.Name(), name),
+			Project:     t.TempDir(),
+			Backend:     db.BackendMemory,
+			Environment: map[string]config.EnvironmentConfig{},
+		}
+		for env := range envs {
+			ws.Environment[env] = config.EnvironmentConfig{}
+		}
+		workspaces = append(workspaces, ws)
+	}
+	f := config.NewFile(workspaces, key)
+	for _, ws := range workspaces {
+		client, err := db.NewClientFrom(f, ws, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for env, values := range vars[ws.Name] {
+			if err := client.CreateBucket(env); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range values {
+				if err := client.AddKey(env, db.Config{Key: []byte(k), Value: []byte(v)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		// In-memory workspaces are dropped with their last client.
+		t.Cleanup(func() { client.Close() })
+	}
+	return NewFrom(f)
+}
+
+func TestExpand(t *testing.T) {
+	r := newTestResolver(t, map[string]map[string]map[string]string{
+		"app": {
+			"dev": {
+				"HOST":     "localhost",
+				"PORT":     "5432",
+				"URL":      "postgres://${HOST}:${PORT}/db",
+				"NESTED":   "${URL}?sslmode=off",
+				"SHARED":   "${prod:HOST}",
+				"OTHER":    "${infra/dev:REGION}",
+				"LITERAL":  "$${HOST} and $${",
+				"PLAIN":    "no references",
+				"DOLLAR":   "cost $5",
+				"ADJACENT": "${HOST}${PORT}",
+			},
+			"prod": {
+				"HOST": "db.internal",
+			},
+		},
+		"infra": {
+			"dev": {
+				"REGION": "eu-west-1",
+			},
+		},
+	})
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"URL", "postgres://localhost:5432/db"},
+		{"NESTED", "postgres://localhost:5432/db?sslmode=off"},
+		{"SHARED", "db.internal"},
+		{"OTHER", "eu-west-1"},
+		{"LITERAL", "${HOST} and ${"},
+		{"PLAIN", "no references"},
+		{"DOLLAR", "cost $5"},
+		{"ADJACENT", "localhost5432"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			v, err := r.LookupVar("app", "dev", tt.key, false)
+			if err != nil {
+				t.Fatalf("LookupVar returned %v", err)
+			}
+			if v.Value != tt.want {
+				t.Errorf("%s = %q, want %q", tt.key, v.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	r := newTestResolver(t, map[string]map[string]map[string]string{
+		"app": {
+			"dev": {
+				"SELF":         "${SELF}",
+				"A":            "${B}",
+				"B":            "${C}",
+				"C":            "${A}",
+				"UNDEFINED":    "${MISSING}",
+				"OTHER_ENV":    "${staging:KEY}",
+				"DEFAULT":      "${HOST:-localhost}",
+				"ASSIGN":       "${HOST:=localhost}",
+				"UNTERMINATED": "${HOST",
+				"EMPTY":        "${}",
+			},
+		},
+	})
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"SELF", "reference cycle: app/dev:SELF -> app/dev:SELF"},
+		{"A", "reference cycle: app/dev:A -> app/dev:B -> app/dev:C -> app/dev:A"},
+		{"UNDEFINED", "undefined variable app/dev:MISSING"},
+		{"OTHER_ENV", "staging"},
+		{"DEFAULT", "shell modifiers are not supported"},
+		{"ASSIGN", "shell modifiers are not supported"},
+		{"UNTERMINATED", "unterminated reference"},
+		{"EMPTY", "invalid reference ${}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			v, err := r.LookupVar("app", "dev", tt.key, false)
+			if err == nil {
+				t.Fatalf("LookupVar = %q, want an error", v.Value)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LookupVar returned %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	// Failures are reported per key and the rest still expands.
+	_, failed, err := r.ExpandEach("app", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != len(tests)+2 {
+		t.Errorf("ExpandEach failed %d keys, want %d", len(failed), len(tests)+2)
+	}
+}
+
+func TestExpandValue(t *testing.T) {
+	r := newTestResolver(t, map[string]map[string]map[string]string{
+		"app": {
+			"dev": {
+				"HOST": "localhost",
+				"URL":  "http://${HOST}",
+			},
+		},
+	})
+	tests := []struct {
+		key     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"NEW", "${HOST}:80", "localhost:80", false},
+		{"HOST", "example.com", "example.com", false},
+		{"HOST", "${URL}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			got, err := NewFrom(r.file).ExpandValue("app", "dev", tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandValue returned %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandValue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	vars := map[string]map[string]map[string]string{
+		"app": {"dev": {"PLAIN": "value", "API_TOKEN": "t"}},
+	}
+	tests := []struct {
+		name    string
+		project bool
+		cwd     string
+		file    string
+		secret  map[string]bool
+	}{
+		{
+			name:    "project schema",
+			project: true,
+			file:    "keys:\n  PLAIN:\n    secret: true\n  API_TOKEN:\n    secret: false\n",
+			secret:  map[string]bool{"PLAIN": true, "API_TOKEN": false},
+		},
+		{
+			name:    "broken project schema",
+			project: true,
+			file:    "keys: [",
+			secret:  map[string]bool{"PLAIN": false, "API_TOKEN": true},
+		},
+		{
+			name:   "no project ignores the current directory",
+			cwd:    "keys:\n  PLAIN:\n    secret: true\n",
+			secret: map[string]bool{"PLAIN": false, "API_TOKEN": true},
+		},
+		{
+			name:   "no project ignores a broken schema in the current directory",
+			cwd:    "keys: [",
+			secret: map[string]bool{"PLAIN": false, "API_TOKEN": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t, vars)
+			ws := &r.file.Workspaces[0]
+			if tt.project {
+				writeSchema(t, ws.Project, tt.file)
+			} else {
+				ws.Project = ""
+			}
+			if tt.cwd != "" {
+				dir := t.TempDir()
+				writeSchema(t, dir, tt.cwd)
+				chdir(t, dir)
+			}
+
+			loaded, err := r.Load("app", "dev", false)
+			if err != nil {
+				t.Fatalf("Load returned %v", err)
+			}
+			for key, want := range tt.secret {
+				if got := loaded[key].Secret; got != want {
+					t.Errorf("%s secret = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func writeSchema(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, schema.FileName), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
@@ -0,0 +1,276 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Brian-Kariu/ryuk/internal/secret"
+)
+
+// FileName is the schema file looked up in a workspace's project path.
+const FileName = "ryuk.schema.yaml"
+
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeBool     = "bool"
+	TypeURL      = "url"
+	TypeDuration = "duration"
+	TypeJSON     = "json"
+)
+
+// Types lists the values accepted by the type field of a key.
+var Types = []string{TypeString, TypeInt, TypeBool, TypeURL, TypeDuration, TypeJSON}
+
+// Schema describes the variables a workspace expects.
+//
+//	keys:
+//	  PORT:
+//	    type: int
+//	    default: "8080"
+//	  DATABASE_URL:
+//	    type: url
+//	    pattern: ^postgres://
+//	    required: [stg, prod]
+//	  LOG_LEVEL:
+//	    allowed: [debug, info, warn, error]
+//	    required: true
+//	  SIGNING_SALT:
+//	    secret: true
+type Schema struct {
+	Keys map[string]*Key `yaml:"keys"`
+}
+
+type Key struct {
+	Type        string   `yaml:"type"`
+	Pattern     string   `yaml:"pattern"`
+	Allowed     []string `yaml:"allowed"`
+	Required    Required `yaml:"required"`
+	Default     *string  `yaml:"default"`
+	Description string   `yaml:"description"`
+	// Secret overrides the classification guessed from the key name.
+	Secret *bool `yaml:"secret"`
+
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Required is either true for every environment or the list of
+// environments that must define the key.
+type Required struct {
+	All  bool
+	Envs []string
+}
+
+func (r *Required) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode(&r.Envs)
+	}
+	return node.Decode(&r.All)
+}
+
+// In reports whether the key is required in env.
+func (r Required) In(env string) bool {
+	if r.All {
+		return true
+	}
+	for _, e := range r.Envs {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// Problem is a key that failed validation.
+type Problem struct {
+	Key     string `json:"key" yaml:"key"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Key, p.Message)
+}
+
+// Path returns where the schema of a project is kept. An empty project
+// uses the current directory.
+func Path(project string) string {
+	return filepath.Join(project, FileName)
+}
+
+// ForProject loads the schema kept in the project path of a workspace.
+// Workspaces without a project path have no schema: the current directory
+// belongs to whatever runs ryuk, not to the workspace.
+func ForProject(project string) (*Schema, error) {
+	if project == "" {
+		return nil, nil
+	}
+	return Load(Path(project))
+}
+
+// Load reads and checks a schema file. A missing file returns a nil schema
+// and no error, since schemas are optional.
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	if s.Keys == nil {
+		s.Keys = map[string]*Key{}
+	}
+	for name, key := range s.Keys {
+		if key == nil {
+			key = &Key{}
+			s.Keys[name] = key
+		}
+		key.name = name
+		if key.Type == "" {
+			key.Type = TypeString
+		}
+		if !validType(key.Type) {
+			return nil, fmt.Errorf("%s: unknown type %q, expected one of %s", name, key.Type, strings.Join(Types, ", "))
+		}
+		if key.Pattern != "" {
+			pattern, err := regexp.Compile(key.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern: %v", name, err)
+			}
+			key.pattern = pattern
+		}
+		if key.Default != nil {
+			if err := key.Check(*key.Default); err != nil {
+				return nil, fmt.Errorf("%s: invalid default: %v", name, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+func validType(t string) bool {
+	for _, known := range Types {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecret reports whether the key is a secret, going by the schema and the
+// key name.
+func (k *Key) IsSecret() bool {
+	return secret.Classify(k.name, nil, k.Secret)
+}
+
+// quote formats value for an error message without leaking secrets.
+func (k *Key) quote(value string) string {
+	if k.IsSecret() {
+		return "value"
+	}
+	return strconv.Quote(value)
+}
+
+// Check returns an error when value does not satisfy the key.
+func (k *Key) Check(value string) error {
+	if err := checkType(k.Type, value, k.quote(value)); err != nil {
+		return err
+	}
+	if k.pattern != nil && !k.pattern.MatchString(value) {
+		return fmt.Errorf("%s does not match %s", k.quote(value), k.Pattern)
+	}
+	if len(k.Allowed) > 0 {
+		for _, allowed := range k.Allowed {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not one of %s", k.quote(value), strings.Join(k.Allowed, ", "))
+	}
+	return nil
+}
+
+func checkType(t, value, quoted string) error {
+	switch t {
+	case TypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("%s is not an int", quoted)
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s is not a bool", quoted)
+		}
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s is not an absolute url", quoted)
+		}
+	case TypeDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%s is not a duration", quoted)
+		}
+	case TypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("%s is not valid json", quoted)
+		}
+	}
+	return nil
+}
+
+// CheckValue validates a single value. Keys missing from the schema are
+// accepted.
+func (s *Schema) CheckValue(key, value string) error {
+	if s == nil {
+		return nil
+	}
+	k, ok := s.Keys[key]
+	if !ok {
+		return nil
+	}
+	return k.Check(value)
+}
+
+// Validate checks the variables of env against the schema and returns the
+// problems sorted by key. Required keys with a default are not reported as
+// missing.
+func (s *Schema) Validate(env string, vars map[string]string) []Problem {
+	problems := []Problem{}
+	if s == nil {
+		return problems
+	}
+	for name, key := range s.Keys {
+		value, ok := vars[name]
+		if !ok {
+			if key.Required.In(env) && key.Default == nil {
+				problems = append(problems, Problem{Key: name, Message: "missing"})
+			}
+			continue
+		}
+		if err := key.Check(value); err != nil {
+			problems = append(problems, Problem{Key: name, Message: err.Error()})
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Key < problems[j].Key
+	})
+	return problems
+}
@@ -0,0 +1,55 @@
+// Package secret decides which variables hold secrets and masks them in
+// output.
+package secret
+
+import "strings"
+
+// Mask is shown instead of a secret value.
+const Mask = "********"
+
+// suffixes are the name endings that mark a key as secret when it was not
+// classified explicitly, e.g. GITHUB_TOKEN or DB_PASSWORD.
+var suffixes = []string{
+	"TOKEN",
+	"PASSWORD",
+	"PASSWD",
+	"PASS",
+	"PWD",
+	"SECRET",
+	"KEY",
+	"CREDENTIALS",
+	"CERT",
+}
+
+// LooksSecret reports whether the name of key suggests a secret.
+func LooksSecret(key string) bool {
+	name := strings.ToUpper(key)
+	for _, suffix := range suffixes {
+		if name == suffix || strings.HasSuffix(name, "_"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Classify decides whether key holds a secret. A flag set with ryuk var set
+// --secret wins, then the secret field of the schema, then the name of the
+// key. Either of flag and schema may be nil.
+func Classify(key string, flag, schema *bool) bool {
+	if flag != nil {
+		return *flag
+	}
+	if schema != nil {
+		return *schema
+	}
+	return LooksSecret(key)
+}
+
+// Show returns value, or Mask when it is a secret that was not revealed.
+// Empty values are returned as they are.
+func Show(value string, secret, reveal bool) string {
+	if secret && !reveal && value != "" {
+		return Mask
+	}
+	return value
+}
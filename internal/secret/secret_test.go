@@ -0,0 +1,85 @@
+package secret
+
+import "testing"
+
+func TestLooksSecret(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"GITHUB_TOKEN", true},
+		{"DB_PASSWORD", true},
+		{"DB_PASSWD", true},
+		{"DB_PASS", true},
+		{"DB_PWD", true},
+		{"CLIENT_SECRET", true},
+		{"API_KEY", true},
+		{"GCP_CREDENTIALS", true},
+		{"TLS_CERT", true},
+		{"KEY", true},
+		{"CERT", true},
+		{"api_key", true},
+		{"Tls_Cert", true},
+		{"PORT", false},
+		{"DATABASE_URL", false},
+		{"MONKEY", false},
+		{"CERTIFICATE_PATH", false},
+		{"KEY_ID", false},
+		{"CERT_DIR", false},
+		{"PASSTHROUGH", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := LooksSecret(tt.key); got != tt.want {
+				t.Errorf("LooksSecret(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		key    string
+		flag   *bool
+		schema *bool
+		want   bool
+	}{
+		{"name only, secret", "API_KEY", nil, nil, true},
+		{"name only, plain", "PORT", nil, nil, false},
+		{"schema marks a plain name secret", "PORT", nil, &yes, true},
+		{"schema marks a secret name plain", "SSH_CERT", nil, &no, false},
+		{"flag marks a plain name secret", "PORT", &yes, nil, true},
+		{"flag marks a secret name plain", "API_KEY", &no, nil, false},
+		{"flag wins over schema", "PORT", &no, &yes, false},
+		{"flag wins over schema the other way", "API_KEY", &yes, &no, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.key, tt.flag, tt.schema); got != tt.want {
+				t.Errorf("Classify(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShow(t *testing.T) {
+	tests := []struct {
+		value  string
+		secret bool
+		reveal bool
+		want   string
+	}{
+		{"hunter2", true, false, Mask},
+		{"hunter2", true, true, "hunter2"},
+		{"8080", false, false, "8080"},
+		{"", true, false, ""},
+	}
+	for _, tt := range tests {
+		if got := Show(tt.value, tt.secret, tt.reveal); got != tt.want {
+			t.Errorf("Show(%q, %v, %v) = %q, want %q", tt.value, tt.secret, tt.reveal, got, tt.want)
+		}
+	}
+}
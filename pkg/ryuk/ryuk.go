@@ -0,0 +1,188 @@
+// Package ryuk reads and writes ryuk workspaces from Go programs.
+//
+//	store, err := ryuk.Open("myapp")
+//	if err != nil {
+//		return err
+//	}
+//	var cfg struct {
+//		Port  int    `env:"PORT,required"`
+//		DBURL string `env:"DATABASE_URL"`
+//	}
+//	err = store.Unmarshal("prod", &cfg)
+//
+// Values are read the same way as ryuk get: inherited variables are included
+// and ${...} references are expanded.
+package ryuk
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Brian-Kariu/ryuk/config"
+	"github.com/Brian-Kariu/ryuk/db"
+	"github.com/Brian-Kariu/ryuk/internal/resolve"
+	"github.com/Brian-Kariu/ryuk/internal/schema"
+)
+
+// Errors returned by a Store can be matched with errors.Is.
+var (
+	ErrWorkspaceNotFound = db.ErrWorkspaceNotFound
+	ErrEnvNotFound       = db.ErrEnvNotFound
+	ErrKeyNotFound       = db.ErrKeyNotFound
+	ErrLocked            = db.ErrLocked
+)
+
+// Store is an open workspace.
+type Store struct {
+	file      *config.File
+	workspace config.WorkspaceConfig
+	// held keeps the data of an in-memory workspace alive until Close.
+	held io.Closer
+}
+
+// Open reads the ryuk config file and returns the named workspace. Each
+// call reads the file again, and the global viper instance of the calling
+// program is left alone.
+func Open(workspace string) (*Store, error) {
+	f, err := config.LoadFile("")
+	if err != nil {
+		return nil, err
+	}
+	ws, err := f.GetWorkspace(workspace)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{file: f, workspace: ws}, nil
+}
+
+// memoryStores numbers the stores of OpenMemory so each call gets its own.
+var memoryStores atomic.Int64
+
+// OpenMemory returns a new empty workspace with the given environments that
+// is kept in memory until the store is closed. It is meant for tests of code
+// reading its configuration through a Store; nothing is written to disk and
+// the ryuk config file is not read.
+func OpenMemory(workspace string, envs ...string) (*Store, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	ws := config.WorkspaceConfig{
+		ID:          fmt.Sprintf("memory-%d", memoryStores.Add(1)),
+		Name:        workspace,
+		Backend:     db.BackendMemory,
+		Environment: map[string]config.EnvironmentConfig{},
+	}
+	ws.DB = ws.ID
+	f := config.NewFile([]config.WorkspaceConfig{ws}, key)
+	client, err := db.NewClientFrom(f, ws, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		if err := db.ValidateEnvName(env); err != nil {
+			client.Close()
+			return nil, err
+		}
+		if err := client.CreateBucket(env); err != nil {
+			client.Close()
+			return nil, err
+		}
+		ws.Environment[env] = config.EnvironmentConfig{}
+	}
+	return &Store{file: f, workspace: ws, held: client}, nil
+}
+
+// Close releases the store. The data of a store returned by OpenMemory is
+// discarded; stores returned by Open hold nothing between calls, so closing
+// them is optional.
+func (s *Store) Close() error {
+	if s.held == nil {
+		return nil
+	}
+	err := s.held.Close()
+	s.held = nil
+	return err
+}
+
+// Workspace returns the name of the workspace.
+func (s *Store) Workspace() string {
+	return s.workspace.Name
+}
+
+// Get returns the effective value of key in env.
+func (s *Store) Get(env, key string) (string, error) {
+	v, err := resolve.NewFrom(s.file).LookupVar(s.workspace.Name, env, key, false)
+	return v.Value, err
+}
+
+// List returns every effective variable of env.
+func (s *Store) List(env string) (map[string]string, error) {
+	vars, err := resolve.NewFrom(s.file).Load(s.workspace.Name, env, false)
+	if err != nil {
+		return nil, err
+	}
+	return resolve.Values(vars), nil
+}
+
+// Lookup returns a function reporting the variables of env, in the shape
+// expected by os.LookupEnv and by the LoadFrom function of code generated by
+// ryuk codegen go.
+func (s *Store) Lookup(env string) (func(string) (string, bool), error) {
+	vars, err := s.List(env)
+	if err != nil {
+		return nil, err
+	}
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}, nil
+}
+
+// Set stores value under key in env. Values are checked against the
+// workspace schema like ryuk var set does, after expanding their references.
+// Values whose references can not be expanded yet are stored unchecked.
+func (s *Store) Set(env, key, value string) error {
+	if err := s.checkEnv(env); err != nil {
+		return err
+	}
+	sch, err := schema.ForProject(s.workspace.Project)
+	if err != nil {
+		return err
+	}
+	checked := value
+	if strings.Contains(value, "${") {
+		checked, err = resolve.NewFrom(s.file).ExpandValue(s.workspace.Name, env, key, value)
+	}
+	if err == nil {
+		if err := sch.CheckValue(key, checked); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+	}
+	client, err := db.NewClientFrom(s.file, s.workspace, false)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.AddKey(env, db.Config{Key: []byte(key), Value: []byte(value)})
+}
+
+// Delete removes key from env. Inherited values are not affected.
+func (s *Store) Delete(env, key string) error {
+	if err := s.checkEnv(env); err != nil {
+		return err
+	}
+	client, err := db.NewClientFrom(s.file, s.workspace, false)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.DeleteKey(env, key)
+}
+
+func (s *Store) checkEnv(env string) error {
+	return s.workspace.CheckEnv(env)
+}
@@ -0,0 +1,142 @@
+package ryuk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func openMemory(t *testing.T, envs ...string) *Store {
+	t.Helper()
+	s, err := OpenMemory("app", envs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestOpenMemory(t *testing.T) {
+	s := openMemory(t, "dev", "prod")
+	if got := s.Workspace(); got != "app" {
+		t.Errorf("Workspace() = %q, want app", got)
+	}
+	for _, env := range []string{"dev", "prod"} {
+		vars, err := s.List(env)
+		if err != nil {
+			t.Fatalf("List(%s) returned %v", env, err)
+		}
+		if len(vars) != 0 {
+			t.Errorf("List(%s) = %v, want no variables", env, vars)
+		}
+	}
+	if _, err := s.List("stg"); !errors.Is(err, ErrEnvNotFound) {
+		t.Errorf("List(stg) returned %v, want ErrEnvNotFound", err)
+	}
+
+	// Every call returns a workspace of its own.
+	if err := s.Set("dev", "PORT", "8080"); err != nil {
+		t.Fatal(err)
+	}
+	other := openMemory(t, "dev")
+	if _, err := other.Get("dev", "PORT"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Get from another store returned %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	s, err := OpenMemory("app", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("dev", "PORT", "8080"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("second Close returned %v", err)
+	}
+	// The environment went with the rest of the data.
+	if _, err := s.Get("dev", "PORT"); !errors.Is(err, ErrEnvNotFound) {
+		t.Errorf("Get after Close returned %v, want the data to be gone", err)
+	}
+}
+
+func TestSetDelete(t *testing.T) {
+	s := openMemory(t, "dev")
+	steps := []struct {
+		name    string
+		run     func() error
+		wantErr error
+		want    map[string]string
+	}{
+		{
+			name: "set",
+			run:  func() error { return s.Set("dev", "HOST", "localhost") },
+			want: map[string]string{"HOST": "localhost"},
+		},
+		{
+			name: "set with a reference",
+			run:  func() error { return s.Set("dev", "URL", "http://${HOST}:8080") },
+			want: map[string]string{"HOST": "localhost", "URL": "http://localhost:8080"},
+		},
+		{
+			name: "overwrite",
+			run:  func() error { return s.Set("dev", "HOST", "example.com") },
+			want: map[string]string{"HOST": "example.com", "URL": "http://example.com:8080"},
+		},
+		{
+			name: "delete",
+			run:  func() error { return s.Delete("dev", "URL") },
+			want: map[string]string{"HOST": "example.com"},
+		},
+		{
+			name:    "delete a missing key",
+			run:     func() error { return s.Delete("dev", "URL") },
+			wantErr: ErrKeyNotFound,
+			want:    map[string]string{"HOST": "example.com"},
+		},
+		{
+			name:    "set in a missing environment",
+			run:     func() error { return s.Set("prod", "HOST", "db") },
+			wantErr: ErrEnvNotFound,
+			want:    map[string]string{"HOST": "example.com"},
+		},
+		{
+			name:    "delete in a missing environment",
+			run:     func() error { return s.Delete("prod", "HOST") },
+			wantErr: ErrEnvNotFound,
+			want:    map[string]string{"HOST": "example.com"},
+		},
+	}
+	for _, step := range steps {
+		err := step.run()
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: returned %v, want %v", step.name, err, step.wantErr)
+		}
+		got, err := s.List("dev")
+		if err != nil {
+			t.Fatalf("%s: List returned %v", step.name, err)
+		}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s: List = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	value, err := s.Get("dev", "HOST")
+	if err != nil || value != "example.com" {
+		t.Errorf("Get(HOST) = %q, %v, want example.com", value, err)
+	}
+	lookup, err := s.Lookup("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := lookup("HOST"); !ok || value != "example.com" {
+		t.Errorf("lookup(HOST) = %q, %v, want example.com", value, ok)
+	}
+	if _, ok := lookup("URL"); ok {
+		t.Error("lookup(URL) found a deleted key")
+	}
+}
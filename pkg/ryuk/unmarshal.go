@@ -0,0 +1,109 @@
+package ryuk
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Unmarshal fills the fields of the struct pointed to by v from the
+// variables of env. Fields are matched by their env tag, for example
+// `env:"PORT"`; add ",required" to fail when the variable is not set. Fields
+// without a tag and variables that are not set are left untouched.
+//
+// Supported field types are strings, bools, integers, floats,
+// time.Duration and anything implementing encoding.TextUnmarshaler.
+func (s *Store) Unmarshal(env string, v any) error {
+	lookup, err := s.Lookup(env)
+	if err != nil {
+		return err
+	}
+	return unmarshal(lookup, v)
+}
+
+func unmarshal(lookup func(string) (string, bool), v any) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ryuk: Unmarshal needs a pointer to a struct, got %T", v)
+	}
+	value := ptr.Elem()
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		key, options, _ := strings.Cut(tag, ",")
+		raw, ok := lookup(key)
+		if !ok {
+			if options == "required" {
+				return fmt.Errorf("ryuk: %s is not set", key)
+			}
+			continue
+		}
+		if err := setField(value.Field(i), raw); err != nil {
+			return fmt.Errorf("ryuk: %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(raw))
+		}
+	}
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setField(field.Elem(), raw)
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package ryuk
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type settings struct {
+	Name     string        `env:"NAME"`
+	Debug    bool          `env:"DEBUG"`
+	Port     int           `env:"PORT,required"`
+	Workers  uint8         `env:"WORKERS"`
+	Ratio    float64       `env:"RATIO"`
+	Timeout  time.Duration `env:"TIMEOUT"`
+	Retries  *int          `env:"RETRIES"`
+	Addr     net.IP        `env:"ADDR"`
+	Skipped  string        `env:"-"`
+	Untagged string
+	internal string `env:"INTERNAL"`
+}
+
+func lookupFrom(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	retries := 3
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		start   settings
+		want    settings
+		wantErr string
+	}{
+		{
+			name: "every type",
+			vars: map[string]string{
+				"NAME":     "api",
+				"DEBUG":    "true",
+				"PORT":     "8080",
+				"WORKERS":  "4",
+				"RATIO":    "0.5",
+				"TIMEOUT":  "1m30s",
+				"RETRIES":  "3",
+				"ADDR":     "10.0.0.1",
+				"INTERNAL": "x",
+				"Skipped":  "x",
+				"Untagged": "x",
+			},
+			want: settings{
+				Name:    "api",
+				Debug:   true,
+				Port:    8080,
+				Workers: 4,
+				Ratio:   0.5,
+				Timeout: 90 * time.Second,
+				Retries: &retries,
+				Addr:    net.ParseIP("10.0.0.1"),
+			},
+		},
+		{
+			name:  "missing variables keep their field",
+			vars:  map[string]string{"PORT": "80"},
+			start: settings{Name: "default", Skipped: "kept", Untagged: "kept"},
+			want:  settings{Name: "default", Port: 80, Skipped: "kept", Untagged: "kept"},
+		},
+		{
+			name:  "empty value is set",
+			vars:  map[string]string{"PORT": "80", "NAME": ""},
+			start: settings{Name: "default"},
+			want:  settings{Port: 80},
+		},
+		{
+			name:    "required variable missing",
+			vars:    map[string]string{"NAME": "api"},
+			wantErr: "ryuk: PORT is not set",
+		},
+		{
+			name:    "invalid int",
+			vars:    map[string]string{"PORT": "eighty"},
+			wantErr: "ryuk: PORT:",
+		},
+		{
+			name:    "int out of range",
+			vars:    map[string]string{"PORT": "80", "WORKERS": "300"},
+			wantErr: "ryuk: WORKERS:",
+		},
+		{
+			name:    "invalid bool",
+			vars:    map[string]string{"PORT": "80", "DEBUG": "maybe"},
+			wantErr: "ryuk: DEBUG:",
+		},
+		{
+			name:    "invalid duration",
+			vars:    map[string]string{"PORT": "80", "TIMEOUT": "90"},
+			wantErr: "ryuk: TIMEOUT:",
+		},
+		{
+			name:    "invalid text",
+			vars:    map[string]string{"PORT": "80", "ADDR": "not an ip"},
+			wantErr: "ryuk: ADDR:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.start
+			err := unmarshal(lookupFrom(tt.vars), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("unmarshal returned %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshal returned %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unmarshal = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTarget(t *testing.T) {
+	var c settings
+	var unsupported struct {
+		Tags []string `env:"TAGS"`
+	}
+	tests := []struct {
+		name   string
+		target any
+		ok     bool
+	}{
+		{"pointer to struct", &c, true},
+		{"struct", c, false},
+		{"nil pointer", (*settings)(nil), false},
+		{"pointer to string", new(string), false},
+		{"unsupported field", &unsupported, false},
+	}
+	vars := lookupFrom(map[string]string{"PORT": "80", "TAGS": "a,b"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := unmarshal(vars, tt.target); (err == nil) != tt.ok {
+				t.Errorf("unmarshal returned %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStoreUnmarshal(t *testing.T) {
+	s := openMemory(t, "dev")
+	for key, value := range map[string]string{"HOST": "localhost", "PORT": "5432", "NAME": "${HOST}:${PORT}"} {
+		if err := s.Set("dev", key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got settings
+	if err := s.Unmarshal("dev", &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (settings{Name: "localhost:5432", Port: 5432}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal = %+v, want %+v", got, want)
+	}
+	if err := s.Unmarshal("prod", &got); err == nil {
+		t.Error("Unmarshal of a missing environment did not fail")
+	}
+}